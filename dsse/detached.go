@@ -0,0 +1,312 @@
+package dsse
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrPayloadNotDetached indicates that an envelope passed to VerifyDetached
+// embeds its payload.
+var ErrPayloadNotDetached = errors.New("envelope payload is not detached")
+
+// ErrPayloadDetached indicates that an envelope passed to
+// EnvelopeSigner.AddSignatures has no embedded payload to sign.
+var ErrPayloadDetached = errors.New("envelope payload is detached")
+
+var (
+	errIncompleteRead = errors.New("payload was not read completely")
+	errConsumerDone   = errors.New("payload consumer returned")
+)
+
+/*
+SignDetached signs a payload of size bytes read from payload, returning an
+envelope that carries only the payload type and signatures: the payload is
+not embedded and must be distributed alongside the envelope, to be checked
+with EnvelopeVerifier.VerifyDetached.
+
+DSSE has no marker for detached payloads: an envelope is considered detached
+if its payload is empty. An envelope embedding an empty payload is thus
+indistinguishable from a detached one. Verify and VerifyDetached, with an
+empty payload, both accept it, but EnvelopeSigner.AddSignatures rejects it
+with ErrPayloadDetached.
+
+The payload is read once and its PAE is streamed to all signers concurrently.
+Signers implementing StreamSigner consume it incrementally, so memory usage
+does not depend on the payload size. Other signers receive the PAE in a
+single buffer shared between them.
+*/
+func (es *EnvelopeSigner) SignDetached(ctx context.Context, payloadType string, payload io.Reader, size int64) (*Envelope, error) {
+	sigs := make([][]byte, len(es.providers))
+
+	var consumers []func(io.Reader) error
+	var buffered []int
+	for i, signer := range es.providers {
+		if ss, ok := signer.(StreamSigner); ok {
+			consumers = append(consumers, func(r io.Reader) (err error) {
+				sigs[i], err = ss.SignStream(ctx, r)
+				return err
+			})
+			continue
+		}
+		buffered = append(buffered, i)
+	}
+	if len(buffered) > 0 {
+		consumers = append(consumers, func(r io.Reader) error {
+			paeEnc, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			for _, i := range buffered {
+				sigs[i], err = es.providers[i].Sign(ctx, paeEnc)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	errs, err := streamPAE(ctx, payloadType, payload, size, consumers)
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var e = Envelope{
+		PayloadType: payloadType,
+	}
+	for i, signer := range es.providers {
+		keyID, err := signer.KeyID()
+		if err != nil {
+			keyID = ""
+		}
+
+		e.Signatures = append(e.Signatures, Signature{
+			KeyID: keyID,
+			Sig:   base64.StdEncoding.EncodeToString(sigs[i]),
+		})
+	}
+
+	return &e, nil
+}
+
+/*
+VerifyDetached behaves like Verify for an envelope created by
+EnvelopeSigner.SignDetached, checking its signatures against a payload of size
+bytes read from payload. ErrPayloadNotDetached is returned if the envelope
+embeds a payload.
+
+The payload is read once and its PAE is streamed concurrently to every
+verifier that may have produced each signature. Verifiers implementing
+StreamVerifier consume it incrementally, so memory usage does not depend on
+the payload size. Other verifiers receive the PAE in a single buffer shared
+between them.
+*/
+func (ev *EnvelopeVerifier) VerifyDetached(ctx context.Context, e *Envelope, payload io.Reader, size int64) ([]AcceptedKey, error) {
+	result, err := ev.verifyDetached(ctx, e, payload, size, false)
+	if result == nil {
+		return nil, err
+	}
+	return result.AcceptedKeys, err
+}
+
+// VerifyDetachedWithResult behaves like VerifyDetached, but reports the status
+// of every signature like VerifyWithResult.
+func (ev *EnvelopeVerifier) VerifyDetachedWithResult(ctx context.Context, e *Envelope, payload io.Reader, size int64) (*VerificationResult, error) {
+	return ev.verifyDetached(ctx, e, payload, size, true)
+}
+
+func (ev *EnvelopeVerifier) verifyDetached(ctx context.Context, e *Envelope, payload io.Reader, size int64, withResult bool) (*VerificationResult, error) {
+	if e == nil {
+		return nil, ErrNilEnvelope
+	}
+
+	if len(e.Signatures) == 0 {
+		return nil, ErrNoSignature
+	}
+
+	if e.Payload != "" {
+		return nil, ErrPayloadNotDetached
+	}
+
+	type attempt struct {
+		sig, provider int
+	}
+	results := map[attempt]error{}
+	var mu sync.Mutex
+	record := func(a attempt, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		results[a] = err
+	}
+
+	// Try every provider whose keyid does not rule out the signature, as
+	// the payload can only be read once.
+	keyIDs := make([]string, len(ev.providers))
+	for i, v := range ev.providers {
+		keyIDs[i] = providerKeyID(v)
+	}
+	var consumers []func(io.Reader) error
+	var buffered []attempt
+	sigs := make([][]byte, len(e.Signatures))
+	for si, s := range e.Signatures {
+		// Signatures that fail to decode are reported by evaluatePolicy.
+		sig, err := b64Decode(s.Sig)
+		if err != nil {
+			if !withResult {
+				return nil, err
+			}
+			continue
+		}
+		sigs[si] = sig
+
+		for p, v := range ev.providers {
+			if s.KeyID != "" && keyIDs[p] != "" && s.KeyID != keyIDs[p] {
+				continue
+			}
+
+			a := attempt{sig: si, provider: p}
+			if sv, ok := v.(StreamVerifier); ok {
+				consumers = append(consumers, func(r io.Reader) error {
+					err := sv.VerifyStream(ctx, r, sig)
+					record(a, err)
+					return err
+				})
+				continue
+			}
+			buffered = append(buffered, a)
+		}
+	}
+	if len(buffered) > 0 {
+		consumers = append(consumers, func(r io.Reader) error {
+			paeEnc, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			for _, a := range buffered {
+				record(a, ev.providers[a.provider].Verify(ctx, paeEnc, sigs[a.sig]))
+			}
+			return nil
+		})
+	}
+
+	errs, err := streamPAE(ctx, e.PayloadType, payload, size, consumers)
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		// Consumers that stopped before the end of the payload never saw
+		// all of it, whatever they reported.
+		if errors.Is(err, errIncompleteRead) {
+			return nil, err
+		}
+	}
+
+	return ev.evaluatePolicy(ctx, e, withResult, func(sig, provider int, _ []byte) error {
+		err, ok := results[attempt{sig: sig, provider: provider}]
+		if !ok {
+			return errIncompleteRead
+		}
+		return err
+	})
+}
+
+/*
+streamPAE reads payload once and streams PAE(payloadType, payload) to each of
+consumers, each running in its own goroutine. It returns the result of each
+consumer, or an error if the payload could not be read. A consumer returning
+nil without reading until io.EOF is reported as errIncompleteRead.
+*/
+func streamPAE(ctx context.Context, payloadType string, payload io.Reader, size int64, consumers []func(io.Reader) error) ([]error, error) {
+	errs := make([]error, len(consumers))
+	w := &fanOutWriter{ctx: ctx}
+
+	var wg sync.WaitGroup
+	for i, consume := range consumers {
+		pr, pw := io.Pipe()
+		w.writers = append(w.writers, pw)
+
+		wg.Go(func() {
+			r := &eofReader{r: pr}
+			err := consume(r)
+			if err == nil && !r.eof {
+				err = errIncompleteRead
+			}
+			errs[i] = err
+			// Unblock the writer if the consumer stopped early.
+			pr.CloseWithError(errConsumerDone)
+		})
+	}
+
+	pae, err := NewPAEWriter(w, payloadType, size)
+	if err == nil {
+		_, err = io.Copy(pae, payload)
+	}
+	if err == nil {
+		err = pae.Close()
+	}
+	if errors.Is(err, errConsumerDone) {
+		err = nil
+	}
+	for _, pw := range w.writers {
+		if pw != nil {
+			pw.CloseWithError(err)
+		}
+	}
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// fanOutWriter writes to all its writers, dropping those that fail. Unlike
+// io.MultiWriter, one consumer stopping early does not affect the others.
+type fanOutWriter struct {
+	ctx     context.Context
+	writers []*io.PipeWriter
+}
+
+func (w *fanOutWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	active := false
+	for i, pw := range w.writers {
+		if pw == nil {
+			continue
+		}
+		if _, err := pw.Write(p); err != nil {
+			w.writers[i] = nil
+			continue
+		}
+		active = true
+	}
+	if !active {
+		return 0, errConsumerDone
+	}
+	return len(p), nil
+}
+
+// eofReader records whether r was read until io.EOF.
+type eofReader struct {
+	r   io.Reader
+	eof bool
+}
+
+func (r *eofReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
@@ -0,0 +1,306 @@
+package dsse
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// streamSignerVerifier computes an HMAC of the message, either from a buffer
+// or incrementally.
+type streamSignerVerifier struct {
+	keyID string
+	// readLimit, if set, makes SignStream and VerifyStream stop reading after
+	// that many bytes.
+	readLimit int64
+}
+
+func (s *streamSignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
+	return s.SignStream(context.Background(), bytes.NewReader(data))
+}
+
+func (s *streamSignerVerifier) SignStream(_ context.Context, r io.Reader) ([]byte, error) {
+	if s.readLimit > 0 {
+		r = io.LimitReader(r, s.readLimit)
+	}
+	h := hmac.New(sha256.New, []byte(s.keyID))
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func (s *streamSignerVerifier) Verify(ctx context.Context, data, sig []byte) error {
+	return s.VerifyStream(ctx, bytes.NewReader(data), sig)
+}
+
+func (s *streamSignerVerifier) VerifyStream(ctx context.Context, r io.Reader, sig []byte) error {
+	want, err := s.SignStream(ctx, r)
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(want, sig) {
+		return errVerify
+	}
+	return nil
+}
+
+func (s *streamSignerVerifier) KeyID() (string, error) {
+	return s.keyID, nil
+}
+
+func (s *streamSignerVerifier) Public() crypto.PublicKey {
+	return s.keyID
+}
+
+type errReader struct{}
+
+func (errReader) Read(_ []byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func TestNewPAEReader(t *testing.T) {
+	payloadType := "http://example.com/HelloWorld"
+
+	for _, payload := range []string{"", "hello world", "ಠ", strings.Repeat("a", 100000)} {
+		got, err := io.ReadAll(NewPAEReader(payloadType, strings.NewReader(payload), int64(len(payload))))
+		assert.Nil(t, err)
+		assert.Equal(t, PAE(payloadType, []byte(payload)), got)
+	}
+
+	tests := map[string]struct {
+		payload io.Reader
+		size    int64
+	}{
+		"payload shorter than size": {
+			payload: strings.NewReader("hello"),
+			size:    11,
+		},
+		"payload longer than size": {
+			payload: strings.NewReader("hello world"),
+			size:    5,
+		},
+		"negative size": {
+			payload: strings.NewReader(""),
+			size:    -1,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := io.ReadAll(NewPAEReader(payloadType, test.payload, test.size))
+			assert.ErrorIs(t, err, ErrPayloadSizeMismatch)
+		})
+	}
+}
+
+func TestNewPAEWriter(t *testing.T) {
+	payloadType := "http://example.com/HelloWorld"
+	payload := []byte("hello world")
+
+	var b bytes.Buffer
+	w, err := NewPAEWriter(&b, payloadType, int64(len(payload)))
+	assert.Nil(t, err)
+	for _, chunk := range [][]byte{payload[:5], payload[5:], {}} {
+		_, err := w.Write(chunk)
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, w.Close())
+	assert.Equal(t, PAE(payloadType, payload), b.Bytes())
+
+	t.Run("payload shorter than size", func(t *testing.T) {
+		w, err := NewPAEWriter(io.Discard, payloadType, int64(len(payload)))
+		assert.Nil(t, err)
+		_, err = w.Write(payload[:5])
+		assert.Nil(t, err)
+		assert.ErrorIs(t, w.Close(), ErrPayloadSizeMismatch)
+	})
+
+	t.Run("payload longer than size", func(t *testing.T) {
+		w, err := NewPAEWriter(io.Discard, payloadType, 5)
+		assert.Nil(t, err)
+		_, err = w.Write(payload)
+		assert.ErrorIs(t, err, ErrPayloadSizeMismatch)
+	})
+
+	t.Run("negative size", func(t *testing.T) {
+		_, err := NewPAEWriter(io.Discard, payloadType, -1)
+		assert.ErrorIs(t, err, ErrPayloadSizeMismatch)
+	})
+}
+
+func TestSignDetached(t *testing.T) {
+	var payloadType = "http://example.com/HelloWorld"
+	var payload = "hello world"
+
+	var ns nilSignerVerifier
+	ss := &streamSignerVerifier{keyID: "stream"}
+	signer, err := NewEnvelopeSigner(ns, ss)
+	assert.Nil(t, err, "unexpected error")
+
+	env, err := signer.SignDetached(t.Context(), payloadType, strings.NewReader(payload), int64(len(payload)))
+	assert.Nil(t, err, "sign failed")
+	assert.Equal(t, payloadType, env.PayloadType)
+	assert.Empty(t, env.Payload)
+
+	// The signatures are the same as for an embedded payload.
+	embedded, err := signer.SignPayload(t.Context(), payloadType, []byte(payload))
+	assert.Nil(t, err, "sign failed")
+	assert.Equal(t, embedded.Signatures, env.Signatures)
+
+	verifier, err := NewMultiEnvelopeVerifier(2, ns, ss)
+	assert.Nil(t, err, "unexpected error")
+	acceptedKeys, err := verifier.VerifyDetached(t.Context(), env, strings.NewReader(payload), int64(len(payload)))
+	assert.Nil(t, err, "unexpected error")
+	assert.Len(t, acceptedKeys, 2, "unexpected keys")
+
+	t.Run("other payload", func(t *testing.T) {
+		acceptedKeys, err := verifier.VerifyDetached(t.Context(), env, strings.NewReader("hello there"), 11)
+		assert.Empty(t, acceptedKeys)
+		assert.EqualError(t, err, "accepted signatures do not match threshold, Found: 0, Expected 2")
+	})
+
+	t.Run("embedded payload", func(t *testing.T) {
+		_, err := verifier.VerifyDetached(t.Context(), embedded, strings.NewReader(payload), int64(len(payload)))
+		assert.ErrorIs(t, err, ErrPayloadNotDetached)
+	})
+
+	t.Run("empty embedded payload", func(t *testing.T) {
+		// An empty payload cannot be told apart from a detached one.
+		empty, err := signer.SignPayload(t.Context(), payloadType, []byte{})
+		assert.Nil(t, err, "sign failed")
+		assert.Empty(t, empty.Payload)
+
+		acceptedKeys, err := verifier.Verify(t.Context(), empty)
+		assert.Nil(t, err, "unexpected error")
+		assert.Len(t, acceptedKeys, 2, "unexpected keys")
+
+		acceptedKeys, err = verifier.VerifyDetached(t.Context(), empty, strings.NewReader(""), 0)
+		assert.Nil(t, err, "unexpected error")
+		assert.Len(t, acceptedKeys, 2, "unexpected keys")
+
+		err = signer.AddSignatures(t.Context(), empty)
+		assert.ErrorIs(t, err, ErrPayloadDetached)
+	})
+
+	t.Run("size mismatch", func(t *testing.T) {
+		_, err := signer.SignDetached(t.Context(), payloadType, strings.NewReader(payload), 5)
+		assert.ErrorIs(t, err, ErrPayloadSizeMismatch)
+
+		_, err = verifier.VerifyDetached(t.Context(), env, strings.NewReader(payload), 20)
+		assert.ErrorIs(t, err, ErrPayloadSizeMismatch)
+	})
+
+	t.Run("read error", func(t *testing.T) {
+		_, err := signer.SignDetached(t.Context(), payloadType, errReader{}, 5)
+		assert.EqualError(t, err, "read error")
+
+		_, err = verifier.VerifyDetached(t.Context(), env, errReader{}, 5)
+		assert.EqualError(t, err, "read error")
+	})
+
+	t.Run("canceled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		_, err := signer.SignDetached(ctx, payloadType, strings.NewReader(payload), int64(len(payload)))
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("signer error", func(t *testing.T) {
+		var es errsigner
+		signer, err := NewEnvelopeSigner(ss, es)
+		assert.Nil(t, err, "unexpected error")
+
+		_, err = signer.SignDetached(t.Context(), payloadType, strings.NewReader(payload), int64(len(payload)))
+		assert.EqualError(t, err, "signing error")
+	})
+
+	t.Run("incomplete read", func(t *testing.T) {
+		partial := &streamSignerVerifier{keyID: "stream", readLimit: 10}
+		signer, err := NewEnvelopeSigner(partial)
+		assert.Nil(t, err, "unexpected error")
+
+		_, err = signer.SignDetached(t.Context(), payloadType, strings.NewReader(payload), int64(len(payload)))
+		assert.ErrorIs(t, err, errIncompleteRead)
+
+		// A verifier accepting a signature over part of the payload must not
+		// be trusted.
+		sig, err := partial.Sign(t.Context(), PAE(payloadType, []byte(payload)))
+		assert.Nil(t, err, "sign failed")
+		truncated := &Envelope{
+			PayloadType: payloadType,
+			Signatures:  []Signature{{KeyID: "stream", Sig: base64.StdEncoding.EncodeToString(sig)}},
+		}
+
+		verifier, err := NewEnvelopeVerifier(partial)
+		assert.Nil(t, err, "unexpected error")
+
+		_, err = verifier.VerifyDetached(t.Context(), truncated, strings.NewReader(payload), int64(len(payload)))
+		assert.ErrorIs(t, err, errIncompleteRead)
+
+		// The same applies to embedded payloads.
+		_, err = signer.SignPayload(t.Context(), payloadType, []byte(payload))
+		assert.ErrorIs(t, err, errIncompleteRead)
+
+		truncated.Payload = base64.StdEncoding.EncodeToString([]byte(payload))
+		_, err = verifier.Verify(t.Context(), truncated)
+		assert.EqualError(t, err, "accepted signatures do not match threshold, Found: 0, Expected 1")
+	})
+}
+
+func TestVerifyDetachedNoMatch(t *testing.T) {
+	var payloadType = "http://example.com/HelloWorld"
+	var payload = "hello world"
+
+	ss := &streamSignerVerifier{keyID: "stream"}
+	signer, err := NewEnvelopeSigner(ss)
+	assert.Nil(t, err, "unexpected error")
+
+	env, err := signer.SignDetached(t.Context(), payloadType, strings.NewReader(payload), int64(len(payload)))
+	assert.Nil(t, err, "sign failed")
+
+	// No verifier may have produced the signature, so the payload is not
+	// needed to reject it.
+	verifier, err := NewEnvelopeVerifier(&streamSignerVerifier{keyID: "other"})
+	assert.Nil(t, err, "unexpected error")
+	_, err = verifier.VerifyDetached(t.Context(), env, errReader{}, int64(len(payload)))
+	assert.EqualError(t, err, "accepted signatures do not match threshold, Found: 0, Expected 1")
+
+	_, err = verifier.VerifyDetached(t.Context(), nil, strings.NewReader(payload), int64(len(payload)))
+	assert.EqualError(t, err, "cannot verify a nil envelope")
+
+	_, err = verifier.VerifyDetached(t.Context(), &Envelope{PayloadType: payloadType}, strings.NewReader(payload), int64(len(payload)))
+	assert.ErrorIs(t, err, ErrNoSignature)
+}
+
+func TestSignDetachedConstantMemory(t *testing.T) {
+	const size = 64 << 20
+
+	signer, err := NewEnvelopeSigner(&streamSignerVerifier{keyID: "a"}, &streamSignerVerifier{keyID: "b"})
+	assert.Nil(t, err, "unexpected error")
+	verifier, err := NewMultiEnvelopeVerifier(2, &streamSignerVerifier{keyID: "a"}, &streamSignerVerifier{keyID: "b"})
+	assert.Nil(t, err, "unexpected error")
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	env, err := signer.SignDetached(t.Context(), "application/octet-stream", io.LimitReader(rand.NewChaCha8([32]byte{}), size), size)
+	assert.Nil(t, err, "sign failed")
+	_, err = verifier.VerifyDetached(t.Context(), env, io.LimitReader(rand.NewChaCha8([32]byte{}), size), size)
+	assert.Nil(t, err, "unexpected error")
+
+	runtime.ReadMemStats(&after)
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(size/16), "memory usage grows with the payload")
+}
@@ -3,10 +3,21 @@ package dsse
 import (
 	"bytes"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"strconv"
+	"strings"
 )
 
+// ErrInvalidEncoding indicates that the payload or a signature of an envelope
+// is not valid base64.
+var ErrInvalidEncoding = errors.New("unable to base64 decode payload (is payload in the right format?)")
+
+// ErrPayloadSizeMismatch indicates that a detached payload did not have the
+// size it was declared with.
+var ErrPayloadSizeMismatch = errors.New("payload size does not match")
+
 /*
 Envelope captures an envelope as described by the DSSE specification. See here:
 https://github.com/secure-systems-lab/dsse/blob/master/envelope.md
@@ -67,6 +78,93 @@ func PAE(payloadType string, payload []byte) []byte {
 	return b.Bytes()
 }
 
+/*
+NewPAEReader returns a reader of PAE(payloadType, payload) for a payload of
+size bytes, reading payload incrementally instead of holding it in memory.
+As PAE starts with the payload length, size must be known in advance: reads
+fail with ErrPayloadSizeMismatch if payload does not hold exactly size bytes.
+*/
+func NewPAEReader(payloadType string, payload io.Reader, size int64) io.Reader {
+	return io.MultiReader(strings.NewReader(paeHeader(payloadType, size)), &sizedReader{r: payload, remaining: size})
+}
+
+/*
+NewPAEWriter returns a writer that writes PAE(payloadType, payload) to w for a
+payload of size bytes written to it incrementally, for instance to feed the
+PAE of a large file to a hash.Hash. The encoding up to the payload is written
+to w immediately. Writing more than size bytes fails, and Close returns
+ErrPayloadSizeMismatch if fewer were written.
+*/
+func NewPAEWriter(w io.Writer, payloadType string, size int64) (io.WriteCloser, error) {
+	if size < 0 {
+		return nil, ErrPayloadSizeMismatch
+	}
+	if _, err := io.WriteString(w, paeHeader(payloadType, size)); err != nil {
+		return nil, err
+	}
+	return &paeWriter{w: w, remaining: size}, nil
+}
+
+type paeWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (pw *paeWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) > pw.remaining {
+		return 0, fmt.Errorf("%w: payload is longer than declared", ErrPayloadSizeMismatch)
+	}
+	n, err := pw.w.Write(p)
+	pw.remaining -= int64(n)
+	return n, err
+}
+
+func (pw *paeWriter) Close() error {
+	if pw.remaining > 0 {
+		return fmt.Errorf("%w: payload is %d bytes shorter than declared", ErrPayloadSizeMismatch, pw.remaining)
+	}
+	return nil
+}
+
+// paeHeader returns the PAE of a payload of size bytes, up to the payload.
+func paeHeader(payloadType string, size int64) string {
+	return "DSSEv1 " + strconv.Itoa(len(payloadType)) + " " + payloadType + " " + strconv.FormatInt(size, 10) + " "
+}
+
+// sizedReader reads exactly remaining bytes from r.
+type sizedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (s *sizedReader) Read(p []byte) (int, error) {
+	if s.remaining < 0 {
+		return 0, ErrPayloadSizeMismatch
+	}
+	if s.remaining == 0 {
+		// The payload must end at the declared size.
+		var b [1]byte
+		n, err := io.ReadAtLeast(s.r, b[:], 1)
+		if n > 0 {
+			return 0, fmt.Errorf("%w: payload is longer than declared", ErrPayloadSizeMismatch)
+		}
+		return 0, err
+	}
+
+	if int64(len(p)) > s.remaining {
+		p = p[:s.remaining]
+	}
+	n, err := s.r.Read(p)
+	s.remaining -= int64(n)
+	if err == io.EOF {
+		if s.remaining > 0 {
+			return n, fmt.Errorf("%w: payload is %d bytes shorter than declared", ErrPayloadSizeMismatch, s.remaining)
+		}
+		err = nil
+	}
+	return n, err
+}
+
 /*
 Both standard and url encoding are allowed:
 https://github.com/secure-systems-lab/dsse/blob/master/envelope.md
@@ -76,7 +174,7 @@ func b64Decode(s string) ([]byte, error) {
 	if err != nil {
 		b, err = base64.URLEncoding.DecodeString(s)
 		if err != nil {
-			return nil, fmt.Errorf("unable to base64 decode payload (is payload in the right format?)")
+			return nil, ErrInvalidEncoding
 		}
 	}
 
@@ -0,0 +1,183 @@
+package dsse
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidPolicy indicates that a Policy cannot be evaluated.
+	ErrInvalidPolicy = errors.New("invalid policy")
+	// ErrPolicyNotSatisfied indicates that the signatures of an envelope did
+	// not satisfy the Policy of an EnvelopeVerifier.
+	ErrPolicyNotSatisfied = errors.New("policy not satisfied")
+)
+
+/*
+Policy describes the signatures an EnvelopeVerifier requires. A policy is
+either a named group of verifiers with a threshold, created with Group, or a
+combination of policies, created with AllOf and AnyOf. For instance, two
+signatures from the release team and one from the security team:
+
+	AllOf(
+		Group("release", 2, releaseVerifiers...),
+		Group("security", 1, securityVerifiers...),
+	)
+*/
+type Policy interface {
+	// appendGroups appends the groups of the policy to groups, in the order
+	// they appear in the policy.
+	appendGroups(groups []*groupPolicy) []*groupPolicy
+	// evaluate reports whether the policy is satisfied by the results of its
+	// groups, explaining why.
+	evaluate(results map[*groupPolicy]*GroupResult) (bool, string)
+	validate() error
+}
+
+/*
+Group creates a Policy satisfied by signatures from at least threshold of
+verifiers. Signatures count once per keyid, as with NewMultiEnvelopeVerifier.
+Names identify the group in a PolicyResult and must be unique in a policy.
+*/
+func Group(name string, threshold int, verifiers ...Verifier) Policy {
+	return &groupPolicy{
+		name:      name,
+		threshold: threshold,
+		verifiers: verifiers,
+	}
+}
+
+// AllOf creates a Policy satisfied when all of policies are.
+func AllOf(policies ...Policy) Policy {
+	return allOfPolicy(policies)
+}
+
+// AnyOf creates a Policy satisfied when at least one of policies is.
+func AnyOf(policies ...Policy) Policy {
+	return anyOfPolicy(policies)
+}
+
+// PolicyResult explains the outcome of evaluating a Policy for an envelope.
+type PolicyResult struct {
+	// Passed is true if the signatures of the envelope satisfied the policy.
+	Passed bool
+	// Groups holds the result of each group of the policy, in the order they
+	// appear in the policy.
+	Groups []GroupResult
+}
+
+// GroupResult is the outcome of evaluating a Group policy.
+type GroupResult struct {
+	Name      string
+	Threshold int
+	// AcceptedKeys holds the keys of the group's verifiers that signed the
+	// envelope, at most one per keyid.
+	AcceptedKeys []AcceptedKey
+	// Passed is true if AcceptedKeys reached Threshold.
+	Passed bool
+}
+
+// acceptedKeys returns the accepted keys of all groups, at most one per keyid.
+func (r *PolicyResult) acceptedKeys() []AcceptedKey {
+	var acceptedKeys []AcceptedKey
+	seen := map[string]bool{}
+	for _, group := range r.Groups {
+		for _, key := range group.AcceptedKeys {
+			if seen[key.KeyID] {
+				continue
+			}
+			seen[key.KeyID] = true
+			acceptedKeys = append(acceptedKeys, key)
+		}
+	}
+	return acceptedKeys
+}
+
+type groupPolicy struct {
+	name      string
+	threshold int
+	verifiers []Verifier
+}
+
+func (g *groupPolicy) appendGroups(groups []*groupPolicy) []*groupPolicy {
+	return append(groups, g)
+}
+
+func (g *groupPolicy) evaluate(results map[*groupPolicy]*GroupResult) (bool, string) {
+	result := results[g]
+	return result.Passed, fmt.Sprintf("group %q accepted %d of %d required signatures", g.name, len(result.AcceptedKeys), g.threshold)
+}
+
+func (g *groupPolicy) validate() error {
+	if g.name == "" {
+		return fmt.Errorf("%w: group without a name", ErrInvalidPolicy)
+	}
+	if g.threshold <= 0 || g.threshold > len(g.verifiers) {
+		return fmt.Errorf("%w: group %q has threshold %d for %d verifiers", ErrInvalidPolicy, g.name, g.threshold, len(g.verifiers))
+	}
+	return nil
+}
+
+type allOfPolicy []Policy
+
+func (p allOfPolicy) appendGroups(groups []*groupPolicy) []*groupPolicy {
+	for _, policy := range p {
+		groups = policy.appendGroups(groups)
+	}
+	return groups
+}
+
+func (p allOfPolicy) evaluate(results map[*groupPolicy]*GroupResult) (bool, string) {
+	passed := true
+	explanations := make([]string, 0, len(p))
+	for _, policy := range p {
+		ok, explanation := policy.evaluate(results)
+		passed = passed && ok
+		explanations = append(explanations, explanation)
+	}
+	return passed, "all of [" + strings.Join(explanations, "; ") + "]"
+}
+
+func (p allOfPolicy) validate() error {
+	return validatePolicies("all of", p)
+}
+
+type anyOfPolicy []Policy
+
+func (p anyOfPolicy) appendGroups(groups []*groupPolicy) []*groupPolicy {
+	for _, policy := range p {
+		groups = policy.appendGroups(groups)
+	}
+	return groups
+}
+
+func (p anyOfPolicy) evaluate(results map[*groupPolicy]*GroupResult) (bool, string) {
+	passed := false
+	explanations := make([]string, 0, len(p))
+	for _, policy := range p {
+		ok, explanation := policy.evaluate(results)
+		passed = passed || ok
+		explanations = append(explanations, explanation)
+	}
+	return passed, "any of [" + strings.Join(explanations, "; ") + "]"
+}
+
+func (p anyOfPolicy) validate() error {
+	return validatePolicies("any of", p)
+}
+
+func validatePolicies(kind string, policies []Policy) error {
+	if len(policies) == 0 {
+		return fmt.Errorf("%w: %s without policies", ErrInvalidPolicy, kind)
+	}
+	for _, policy := range policies {
+		if policy == nil {
+			return fmt.Errorf("%w: nil policy in %s", ErrInvalidPolicy, kind)
+		}
+		if err := policy.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package dsse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPolicy(t *testing.T) {
+	var payloadType = "http://example.com/HelloWorld"
+	var payload = "hello world"
+
+	release := []Verifier{
+		&streamSignerVerifier{keyID: "release-1"},
+		&streamSignerVerifier{keyID: "release-2"},
+		&streamSignerVerifier{keyID: "release-3"},
+	}
+	security := []Verifier{
+		&streamSignerVerifier{keyID: "security-1"},
+		&streamSignerVerifier{keyID: "security-2"},
+	}
+	sign := func(t *testing.T, keyIDs ...string) *Envelope {
+		t.Helper()
+		var signers []Signer
+		for _, keyID := range keyIDs {
+			signers = append(signers, &streamSignerVerifier{keyID: keyID})
+		}
+		signer, err := NewEnvelopeSigner(signers...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, err := signer.SignPayload(t.Context(), payloadType, []byte(payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return env
+	}
+
+	releaseAndSecurity, err := NewPolicyEnvelopeVerifier(AllOf(
+		Group("release", 2, release...),
+		Group("security", 1, security...),
+	))
+	assert.Nil(t, err, "unexpected error")
+
+	t.Run("policy satisfied", func(t *testing.T) {
+		env := sign(t, "release-1", "release-3", "security-2")
+
+		result, err := releaseAndSecurity.VerifyPolicy(t.Context(), env)
+		assert.Nil(t, err, "unexpected error")
+		assert.True(t, result.Passed)
+		if assert.Len(t, result.Groups, 2) {
+			assert.Equal(t, "release", result.Groups[0].Name)
+			assert.Equal(t, 2, result.Groups[0].Threshold)
+			assert.True(t, result.Groups[0].Passed)
+			assert.Equal(t, []string{"release-1", "release-3"}, acceptedKeyIDs(result.Groups[0].AcceptedKeys))
+			assert.Equal(t, "security", result.Groups[1].Name)
+			assert.True(t, result.Groups[1].Passed)
+			assert.Equal(t, []string{"security-2"}, acceptedKeyIDs(result.Groups[1].AcceptedKeys))
+		}
+
+		acceptedKeys, err := releaseAndSecurity.Verify(t.Context(), env)
+		assert.Nil(t, err, "unexpected error")
+		assert.Equal(t, []string{"release-1", "release-3", "security-2"}, acceptedKeyIDs(acceptedKeys))
+	})
+
+	t.Run("policy not satisfied", func(t *testing.T) {
+		env := sign(t, "release-1", "security-1", "security-2")
+
+		result, err := releaseAndSecurity.VerifyPolicy(t.Context(), env)
+		assert.ErrorIs(t, err, ErrPolicyNotSatisfied)
+		assert.EqualError(t, err, `policy not satisfied: all of [group "release" accepted 1 of 2 required signatures; group "security" accepted 2 of 1 required signatures]`)
+		assert.False(t, result.Passed)
+		if assert.Len(t, result.Groups, 2) {
+			assert.False(t, result.Groups[0].Passed)
+			assert.Equal(t, []string{"release-1"}, acceptedKeyIDs(result.Groups[0].AcceptedKeys))
+			assert.True(t, result.Groups[1].Passed)
+		}
+
+		acceptedKeys, err := releaseAndSecurity.Verify(t.Context(), env)
+		assert.ErrorIs(t, err, ErrPolicyNotSatisfied)
+		assert.Equal(t, []string{"release-1", "security-1", "security-2"}, acceptedKeyIDs(acceptedKeys))
+	})
+
+	t.Run("any of", func(t *testing.T) {
+		verifier, err := NewPolicyEnvelopeVerifier(AnyOf(
+			Group("release", 3, release...),
+			AllOf(
+				Group("release-lead", 1, release[0]),
+				Group("security", 2, security...),
+			),
+		))
+		assert.Nil(t, err, "unexpected error")
+
+		result, err := verifier.VerifyPolicy(t.Context(), sign(t, "release-1", "security-1", "security-2"))
+		assert.Nil(t, err, "unexpected error")
+		assert.True(t, result.Passed)
+		if assert.Len(t, result.Groups, 3) {
+			assert.False(t, result.Groups[0].Passed)
+			assert.True(t, result.Groups[1].Passed)
+			assert.True(t, result.Groups[2].Passed)
+		}
+
+		_, err = verifier.VerifyPolicy(t.Context(), sign(t, "release-1", "release-2", "security-1"))
+		assert.EqualError(t, err, `policy not satisfied: any of [group "release" accepted 2 of 3 required signatures; all of [group "release-lead" accepted 1 of 1 required signatures; group "security" accepted 1 of 2 required signatures]]`)
+	})
+
+	t.Run("detached payload", func(t *testing.T) {
+		signer, err := NewEnvelopeSigner(&streamSignerVerifier{keyID: "release-2"}, &streamSignerVerifier{keyID: "security-1"})
+		assert.Nil(t, err, "unexpected error")
+		env, err := signer.SignDetached(t.Context(), payloadType, strings.NewReader(payload), int64(len(payload)))
+		assert.Nil(t, err, "sign failed")
+
+		acceptedKeys, err := releaseAndSecurity.VerifyDetached(t.Context(), env, strings.NewReader(payload), int64(len(payload)))
+		assert.ErrorIs(t, err, ErrPolicyNotSatisfied)
+		assert.Equal(t, []string{"release-2", "security-1"}, acceptedKeyIDs(acceptedKeys))
+	})
+
+	t.Run("flat threshold", func(t *testing.T) {
+		verifier, err := NewMultiEnvelopeVerifier(2, release...)
+		assert.Nil(t, err, "unexpected error")
+
+		result, err := verifier.VerifyPolicy(t.Context(), sign(t, "release-1"))
+		assert.EqualError(t, err, "accepted signatures do not match threshold, Found: 1, Expected 2")
+		if assert.Len(t, result.Groups, 1) {
+			assert.Equal(t, "", result.Groups[0].Name)
+			assert.False(t, result.Groups[0].Passed)
+		}
+	})
+}
+
+func TestNewPolicyEnvelopeVerifier(t *testing.T) {
+	var ns nilSignerVerifier
+	var null nullSignerVerifier
+
+	tests := map[string]struct {
+		policy     Policy
+		wantErrMsg string
+	}{
+		"nil policy": {
+			policy:     nil,
+			wantErrMsg: "invalid policy: no policy provided",
+		},
+		"group without a name": {
+			policy:     Group("", 1, ns),
+			wantErrMsg: "invalid policy: group without a name",
+		},
+		"threshold too high": {
+			policy:     Group("a", 2, ns),
+			wantErrMsg: `invalid policy: group "a" has threshold 2 for 1 verifiers`,
+		},
+		"threshold too low": {
+			policy:     AllOf(Group("a", 0, ns)),
+			wantErrMsg: `invalid policy: group "a" has threshold 0 for 1 verifiers`,
+		},
+		"empty all of": {
+			policy:     AllOf(),
+			wantErrMsg: "invalid policy: all of without policies",
+		},
+		"nil in any of": {
+			policy:     AnyOf(Group("a", 1, ns), nil),
+			wantErrMsg: "invalid policy: nil policy in any of",
+		},
+		"duplicate group": {
+			policy:     AnyOf(Group("a", 1, ns), AllOf(Group("a", 1, null))),
+			wantErrMsg: `invalid policy: duplicate group "a"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			verifier, err := NewPolicyEnvelopeVerifier(test.policy)
+			assert.Nil(t, verifier)
+			assert.ErrorIs(t, err, ErrInvalidPolicy)
+			assert.EqualError(t, err, test.wantErrMsg)
+		})
+	}
+}
+
+func acceptedKeyIDs(acceptedKeys []AcceptedKey) []string {
+	keyIDs := []string{}
+	for _, key := range acceptedKeys {
+		keyIDs = append(keyIDs, key.KeyID)
+	}
+	return keyIDs
+}
@@ -0,0 +1,80 @@
+package dsse
+
+// SignatureStatus is the outcome of verifying one signature of an envelope.
+type SignatureStatus int
+
+const (
+	// SignatureAccepted indicates that a verifier accepted the signature and
+	// its key counts towards the threshold.
+	SignatureAccepted SignatureStatus = iota + 1
+	// SignatureBad indicates that the verifiers matching the keyid of the
+	// signature all rejected it.
+	SignatureBad
+	// SignatureUnknownKeyID indicates that no verifier matches the keyid of
+	// the signature.
+	SignatureUnknownKeyID
+	// SignatureDuplicateKey indicates that the signature is valid, but was
+	// made by a key that already counts towards the threshold through another
+	// signature, see https://github.com/in-toto/in-toto/pull/251.
+	SignatureDuplicateKey
+	// SignatureDecodeError indicates that the signature is not valid base64.
+	SignatureDecodeError
+)
+
+func (s SignatureStatus) String() string {
+	switch s {
+	case SignatureAccepted:
+		return "accepted"
+	case SignatureBad:
+		return "bad signature"
+	case SignatureUnknownKeyID:
+		return "unknown keyid"
+	case SignatureDuplicateKey:
+		return "duplicate key"
+	case SignatureDecodeError:
+		return "decode error"
+	default:
+		return "unknown"
+	}
+}
+
+// rank orders statuses for signatures checked by several groups of a policy,
+// reporting the most favorable outcome.
+func (s SignatureStatus) rank() int {
+	switch s {
+	case SignatureAccepted:
+		return 4
+	case SignatureDuplicateKey:
+		return 3
+	case SignatureBad:
+		return 2
+	case SignatureUnknownKeyID:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// SignatureResult reports the status of one signature of an envelope.
+type SignatureResult struct {
+	Signature Signature
+	Status    SignatureStatus
+	// KeyID is the keyid of the verifier that accepted the signature, for
+	// SignatureAccepted and SignatureDuplicateKey.
+	KeyID string
+	// Err explains why the signature was not accepted. It wraps
+	// ErrInvalidSignature and the verifier's error, ErrUnknownKeyID,
+	// ErrDuplicateKey or ErrInvalidEncoding depending on Status.
+	Err error
+}
+
+// VerificationResult reports the outcome of verifying an envelope.
+type VerificationResult struct {
+	// AcceptedKeys holds the keys returned by Verify.
+	AcceptedKeys []AcceptedKey
+	// Signatures holds the result of each signature of the envelope, in
+	// order.
+	Signatures []SignatureResult
+	// Policy holds the result of each group of the EnvelopeVerifier's policy.
+	Policy *PolicyResult
+}
@@ -0,0 +1,163 @@
+package dsse
+
+import (
+	"bytes"
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyWithResult(t *testing.T) {
+	var payloadType = "http://example.com/HelloWorld"
+	var payload = "hello world"
+
+	a := &streamSignerVerifier{keyID: "a"}
+	b := &streamSignerVerifier{keyID: "b"}
+	c := &streamSignerVerifier{keyID: "c"}
+
+	sign := func(t *testing.T, s Signer, data string) string {
+		t.Helper()
+		sig, err := s.Sign(t.Context(), PAE(payloadType, []byte(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.StdEncoding.EncodeToString(sig)
+	}
+	signatures := []Signature{
+		{KeyID: "a", Sig: sign(t, a, payload)},
+		{KeyID: "b", Sig: sign(t, b, "other payload")},
+		{KeyID: "c", Sig: sign(t, c, payload)},
+		{KeyID: "a", Sig: sign(t, a, payload)},
+		{KeyID: "a", Sig: sign(t, b, payload)},
+		{KeyID: "a", Sig: "not base 64"},
+	}
+	env := &Envelope{
+		PayloadType: payloadType,
+		Payload:     base64.StdEncoding.EncodeToString([]byte(payload)),
+		Signatures:  signatures,
+	}
+
+	verifier, err := NewMultiEnvelopeVerifier(2, a, b)
+	assert.Nil(t, err, "unexpected error")
+
+	result, err := verifier.VerifyWithResult(t.Context(), env)
+	assert.ErrorIs(t, err, ErrThresholdNotMet)
+	assert.EqualError(t, err, "accepted signatures do not match threshold, Found: 1, Expected 2")
+	assert.Equal(t, []string{"a"}, acceptedKeyIDs(result.AcceptedKeys))
+	assert.False(t, result.Policy.Passed)
+
+	tests := []struct {
+		status  SignatureStatus
+		keyID   string
+		wantErr []error
+	}{
+		{status: SignatureAccepted, keyID: "a"},
+		{status: SignatureBad, wantErr: []error{ErrInvalidSignature, errVerify}},
+		{status: SignatureUnknownKeyID, wantErr: []error{ErrUnknownKeyID}},
+		{status: SignatureDuplicateKey, keyID: "a", wantErr: []error{ErrDuplicateKey}},
+		// An invalid signature reusing the keyid of an accepted key is not
+		// a duplicate.
+		{status: SignatureBad, wantErr: []error{ErrInvalidSignature, errVerify}},
+		{status: SignatureDecodeError, wantErr: []error{ErrInvalidEncoding}},
+	}
+	if assert.Len(t, result.Signatures, len(tests)) {
+		for i, test := range tests {
+			got := result.Signatures[i]
+			assert.Equal(t, signatures[i], got.Signature, "signature %d", i)
+			assert.Equal(t, test.status, got.Status, "signature %d", i)
+			assert.Equal(t, test.keyID, got.KeyID, "signature %d", i)
+			if test.wantErr == nil {
+				assert.Nil(t, got.Err, "signature %d", i)
+			}
+			for _, wantErr := range test.wantErr {
+				assert.ErrorIs(t, got.Err, wantErr, "signature %d", i)
+			}
+		}
+	}
+
+	t.Run("logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+		_, err := verifier.WithLogger(logger).VerifyWithResult(t.Context(), env)
+		assert.ErrorIs(t, err, ErrThresholdNotMet)
+
+		logs := buf.String()
+		assert.Contains(t, logs, `level=INFO msg="envelope signed by different subkeys of the same main key, only one of them is counted towards the threshold" index=3 keyid=a`)
+		assert.Contains(t, logs, `status="bad signature"`)
+		assert.Contains(t, logs, `status="unknown keyid"`)
+		assert.Contains(t, logs, `status="decode error"`)
+		assert.Equal(t, 1, strings.Count(logs, "level=INFO"))
+		assert.Equal(t, 5, strings.Count(logs, "\n"))
+	})
+
+	t.Run("policy", func(t *testing.T) {
+		// Each signature reports its best outcome across groups.
+		verifier, err := NewPolicyEnvelopeVerifier(AllOf(
+			Group("first", 1, a),
+			Group("second", 1, c),
+		))
+		assert.Nil(t, err, "unexpected error")
+
+		result, err := verifier.VerifyWithResult(t.Context(), env)
+		assert.Nil(t, err, "unexpected error")
+		assert.True(t, result.Policy.Passed)
+		assert.Equal(t, []string{"a", "c"}, acceptedKeyIDs(result.AcceptedKeys))
+		assert.Equal(t, SignatureAccepted, result.Signatures[0].Status)
+		assert.Equal(t, SignatureUnknownKeyID, result.Signatures[1].Status)
+		assert.Equal(t, SignatureAccepted, result.Signatures[2].Status)
+	})
+
+	t.Run("detached payload", func(t *testing.T) {
+		detached := *env
+		detached.Payload = ""
+
+		result, err := verifier.VerifyDetachedWithResult(t.Context(), &detached, strings.NewReader(payload), int64(len(payload)))
+		assert.ErrorIs(t, err, ErrThresholdNotMet)
+		statuses := []SignatureStatus{}
+		for _, s := range result.Signatures {
+			statuses = append(statuses, s.Status)
+		}
+		assert.Equal(t, []SignatureStatus{SignatureAccepted, SignatureBad, SignatureUnknownKeyID, SignatureDuplicateKey, SignatureBad, SignatureDecodeError}, statuses)
+	})
+
+	t.Run("Verify", func(t *testing.T) {
+		// Verify fails on signatures that fail to decode.
+		_, err := verifier.Verify(t.Context(), env)
+		assert.ErrorIs(t, err, ErrInvalidEncoding)
+
+		detached := *env
+		detached.Payload = ""
+		_, err = verifier.VerifyDetached(t.Context(), &detached, strings.NewReader(payload), int64(len(payload)))
+		assert.ErrorIs(t, err, ErrInvalidEncoding)
+
+		valid := *env
+		valid.Signatures = signatures[:5]
+		_, err = verifier.Verify(t.Context(), &valid)
+		assert.EqualError(t, err, "accepted signatures do not match threshold, Found: 1, Expected 2")
+		assert.NotErrorIs(t, err, ErrThresholdNotMet)
+	})
+
+	t.Run("sentinel errors", func(t *testing.T) {
+		_, err := verifier.VerifyWithResult(t.Context(), nil)
+		assert.ErrorIs(t, err, ErrNilEnvelope)
+
+		_, err = verifier.VerifyDetached(t.Context(), nil, strings.NewReader(payload), int64(len(payload)))
+		assert.ErrorIs(t, err, ErrNilEnvelope)
+
+		_, err = NewMultiEnvelopeVerifier(3, a, b)
+		assert.ErrorIs(t, err, ErrInvalidThreshold)
+	})
+}
+
+func TestSignatureStatusString(t *testing.T) {
+	assert.Equal(t, "accepted", SignatureAccepted.String())
+	assert.Equal(t, "bad signature", SignatureBad.String())
+	assert.Equal(t, "unknown keyid", SignatureUnknownKeyID.String())
+	assert.Equal(t, "duplicate key", SignatureDuplicateKey.String())
+	assert.Equal(t, "decode error", SignatureDecodeError.String())
+	assert.Equal(t, "unknown", SignatureStatus(0).String())
+}
@@ -5,14 +5,20 @@ https://github.com/secure-systems-lab/dsse
 package dsse
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
+	"fmt"
 )
 
 // ErrNoSigners indicates that no signer was provided.
 var ErrNoSigners = errors.New("no signers provided")
 
+// ErrDuplicateKeyID indicates that an envelope already has a signature by a
+// signer's key.
+var ErrDuplicateKeyID = errors.New("envelope already signed with keyid")
+
 // EnvelopeSigner creates signed Envelopes.
 type EnvelopeSigner struct {
 	providers []Signer
@@ -58,15 +64,95 @@ https://github.com/secure-systems-lab/dsse/blob/master/envelope.md
 One signature will be added for each Signer in the EnvelopeSigner.
 */
 func (es *EnvelopeSigner) SignPayload(ctx context.Context, payloadType string, body []byte) (*Envelope, error) {
-	var e = Envelope{
+	signatures, err := es.sign(ctx, payloadType, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
 		Payload:     base64.StdEncoding.EncodeToString(body),
 		PayloadType: payloadType,
+		Signatures:  signatures,
+	}, nil
+}
+
+/*
+AddSignatures co-signs an envelope that was already signed by another party,
+appending one signature for each Signer in the EnvelopeSigner. The payload
+must decode and is signed as is. ErrDuplicateKeyID is returned if a Signer's
+keyid is already used by a signature in the envelope. On error, the envelope
+is left unchanged.
+
+Envelopes created by SignDetached cannot be co-signed this way, as the payload
+they were signed with is not available: ErrPayloadDetached is returned for
+envelopes without a payload, including envelopes that embed an empty
+payload, as the two cannot be told apart.
+*/
+func (es *EnvelopeSigner) AddSignatures(ctx context.Context, e *Envelope) error {
+	if e == nil {
+		return errors.New("cannot sign a nil envelope")
+	}
+
+	if e.Payload == "" {
+		return ErrPayloadDetached
+	}
+
+	body, err := e.DecodeB64Payload()
+	if err != nil {
+		return err
 	}
 
-	paeEnc := PAE(payloadType, body)
+	// Check for duplicates before signing, which may be costly or require
+	// user interaction.
+	keyIDs := make(map[string]bool, len(e.Signatures))
+	for _, s := range e.Signatures {
+		if s.KeyID != "" {
+			keyIDs[s.KeyID] = true
+		}
+	}
+	for _, signer := range es.providers {
+		keyID, err := signer.KeyID()
+		if err != nil || keyID == "" {
+			continue
+		}
+		if keyIDs[keyID] {
+			return fmt.Errorf("%w: %s", ErrDuplicateKeyID, keyID)
+		}
+		keyIDs[keyID] = true
+	}
 
+	signatures, err := es.sign(ctx, e.PayloadType, body)
+	if err != nil {
+		return err
+	}
+
+	e.Signatures = append(e.Signatures, signatures...)
+	return nil
+}
+
+// sign returns one signature of PAE(payloadType, body) for each Signer in the
+// EnvelopeSigner.
+func (es *EnvelopeSigner) sign(ctx context.Context, payloadType string, body []byte) ([]Signature, error) {
+	var signatures []Signature
+
+	// The PAE is only copied into a buffer for signers that cannot read it
+	// incrementally.
+	var paeEnc []byte
 	for _, signer := range es.providers {
-		sig, err := signer.Sign(ctx, paeEnc)
+		var sig []byte
+		var err error
+		if ss, ok := signer.(StreamSigner); ok {
+			r := &eofReader{r: NewPAEReader(payloadType, bytes.NewReader(body), int64(len(body)))}
+			sig, err = ss.SignStream(ctx, r)
+			if err == nil && !r.eof {
+				err = errIncompleteRead
+			}
+		} else {
+			if paeEnc == nil {
+				paeEnc = PAE(payloadType, body)
+			}
+			sig, err = signer.Sign(ctx, paeEnc)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -75,11 +161,11 @@ func (es *EnvelopeSigner) SignPayload(ctx context.Context, payloadType string, b
 			keyID = ""
 		}
 
-		e.Signatures = append(e.Signatures, Signature{
+		signatures = append(signatures, Signature{
 			KeyID: keyID,
 			Sig:   base64.StdEncoding.EncodeToString(sig),
 		})
 	}
 
-	return &e, nil
+	return signatures, nil
 }
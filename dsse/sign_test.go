@@ -242,6 +242,106 @@ func TestSignError(t *testing.T) {
 	assert.Equal(t, "signing error", err.Error(), "wrong error")
 }
 
+func TestAddSignatures(t *testing.T) {
+	var payloadType = "http://example.com/HelloWorld"
+	var payload = []byte("hello world")
+
+	var ns nilSignerVerifier
+	var null nullSignerVerifier
+	signer, err := NewEnvelopeSigner(ns)
+	assert.Nil(t, err, "unexpected error")
+	cosigner, err := NewEnvelopeSigner(null)
+	assert.Nil(t, err, "unexpected error")
+
+	env, err := signer.SignPayload(t.Context(), payloadType, payload)
+	assert.Nil(t, err, "sign failed")
+
+	err = cosigner.AddSignatures(t.Context(), env)
+	assert.Nil(t, err, "unexpected error")
+	if assert.Len(t, env.Signatures, 2) {
+		assert.Equal(t, "nil", env.Signatures[0].KeyID)
+		assert.Equal(t, "null", env.Signatures[1].KeyID)
+	}
+
+	// Both signatures count towards the threshold.
+	verifier, err := NewMultiEnvelopeVerifier(2, ns, null)
+	assert.Nil(t, err, "unexpected error")
+	acceptedKeys, err := verifier.Verify(t.Context(), env)
+	assert.Nil(t, err, "unexpected error")
+	assert.Len(t, acceptedKeys, 2, "unexpected keys")
+
+	tests := map[string]struct {
+		signer     *EnvelopeSigner
+		env        *Envelope
+		wantErr    error
+		wantErrMsg string
+	}{
+		"already signed": {
+			signer:  cosigner,
+			env:     env,
+			wantErr: ErrDuplicateKeyID,
+		},
+		"duplicate signers": {
+			signer: func() *EnvelopeSigner {
+				s, _ := NewEnvelopeSigner(errsigner(0), errsigner(1))
+				return s
+			}(),
+			env:     env,
+			wantErr: ErrDuplicateKeyID,
+		},
+		"signing error": {
+			signer: func() *EnvelopeSigner {
+				s, _ := NewEnvelopeSigner(errsigner(0))
+				return s
+			}(),
+			env:        env,
+			wantErrMsg: "signing error",
+		},
+		"bad payload": {
+			signer: cosigner,
+			env: &Envelope{
+				PayloadType: payloadType,
+				Payload:     "Not base64",
+			},
+			wantErrMsg: "unable to base64 decode payload (is payload in the right format?)",
+		},
+		"nil envelope": {
+			signer:     cosigner,
+			wantErrMsg: "cannot sign a nil envelope",
+		},
+		"detached payload": {
+			signer: cosigner,
+			env: func() *Envelope {
+				env, err := signer.SignDetached(t.Context(), payloadType, bytes.NewReader(payload), int64(len(payload)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				return env
+			}(),
+			wantErr: ErrPayloadDetached,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var before []Signature
+			if test.env != nil {
+				before = append(before, test.env.Signatures...)
+			}
+
+			err := test.signer.AddSignatures(t.Context(), test.env)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.EqualError(t, err, test.wantErrMsg)
+			}
+			if test.env != nil {
+				assert.Equal(t, before, test.env.Signatures, "envelope modified")
+			}
+		})
+	}
+}
+
 func newEcdsaKey() *ecdsa.PrivateKey {
 	var x big.Int
 	var y big.Int
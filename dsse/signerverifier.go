@@ -3,6 +3,7 @@ package dsse
 import (
 	"context"
 	"crypto"
+	"io"
 )
 
 /*
@@ -32,6 +33,28 @@ type Verifier interface {
 	Public() crypto.PublicKey
 }
 
+/*
+StreamSigner is an optional interface for Signers that can sign a message read
+from r, typically by hashing it incrementally, without holding it in memory.
+EnvelopeSigner.SignDetached uses it to sign payloads of any size in constant
+memory. SignStream must read r until io.EOF and return the same signature Sign
+would return for the full message.
+*/
+type StreamSigner interface {
+	Signer
+	SignStream(ctx context.Context, r io.Reader) ([]byte, error)
+}
+
+/*
+StreamVerifier is the verification counterpart of StreamSigner, used by
+EnvelopeVerifier.VerifyDetached. VerifyStream must read r until io.EOF before
+reporting a valid signature.
+*/
+type StreamVerifier interface {
+	Verifier
+	VerifyStream(ctx context.Context, r io.Reader, sig []byte) error
+}
+
 // SignerVerifier provides both the signing and verification interface.
 type SignerVerifier interface {
 	Signer
@@ -1,20 +1,45 @@
 package dsse
 
 import (
+	"bytes"
 	"context"
 	"crypto"
 	"errors"
 	"fmt"
+	"log/slog"
 
 	"golang.org/x/crypto/ssh"
 )
 
-// ErrNoSignature indicates that an envelope did not contain any signatures.
-var ErrNoSignature = errors.New("no signature found")
+var (
+	// ErrNoSignature indicates that an envelope did not contain any signatures.
+	ErrNoSignature = errors.New("no signature found")
+	// ErrNilEnvelope indicates that a nil envelope was passed for verification.
+	ErrNilEnvelope = errors.New("cannot verify a nil envelope")
+	// ErrInvalidThreshold indicates a threshold lower than one or higher than
+	// the number of verifiers.
+	ErrInvalidThreshold = errors.New("invalid threshold")
+	// ErrThresholdNotMet indicates that fewer keys than the threshold of an
+	// EnvelopeVerifier created by NewMultiEnvelopeVerifier signed an envelope.
+	// It is only wrapped by the errors of VerifyWithResult and
+	// VerifyDetachedWithResult.
+	ErrThresholdNotMet = errors.New("accepted signatures do not match threshold")
+	// ErrInvalidSignature indicates that a signature was rejected by the
+	// verifiers matching its keyid.
+	ErrInvalidSignature = errors.New("invalid signature")
+	// ErrUnknownKeyID indicates that no verifier matches the keyid of a
+	// signature.
+	ErrUnknownKeyID = errors.New("no verifier matches keyid")
+	// ErrDuplicateKey indicates that a signature was made by a key that
+	// already counts towards the threshold.
+	ErrDuplicateKey = errors.New("key already counted towards the threshold")
+)
 
 type EnvelopeVerifier struct {
+	policy Policy
+	// providers holds the verifiers of all groups of the policy, in order.
 	providers []Verifier
-	threshold int
+	logger    *slog.Logger
 }
 
 type AcceptedKey struct {
@@ -23,6 +48,18 @@ type AcceptedKey struct {
 	Sig    Signature
 }
 
+/*
+WithLogger returns a copy of the EnvelopeVerifier that logs the signatures it
+does not accept to logger: signatures by a key that was already counted at
+info level, and other signatures at debug level. By default, nothing is
+logged.
+*/
+func (ev *EnvelopeVerifier) WithLogger(logger *slog.Logger) *EnvelopeVerifier {
+	c := *ev
+	c.logger = logger
+	return &c
+}
+
 func (ev *EnvelopeVerifier) Verify(ctx context.Context, e *Envelope) ([]AcceptedKey, error) {
 	keys, _, err := ev.VerifyAndDecode(ctx, e)
 	return keys, err
@@ -32,8 +69,51 @@ func (ev *EnvelopeVerifier) Verify(ctx context.Context, e *Envelope) ([]Accepted
 // envelope payload, allowing callers who need the payload bytes (e.g., for
 // hashing or further parsing) to avoid a second base64 decode.
 func (ev *EnvelopeVerifier) VerifyAndDecode(ctx context.Context, e *Envelope) ([]AcceptedKey, []byte, error) {
+	result, body, err := ev.verify(ctx, e, false)
+	if result == nil {
+		return nil, nil, err
+	}
+	if err != nil {
+		return result.AcceptedKeys, nil, err
+	}
+
+	return result.AcceptedKeys, body, nil
+}
+
+/*
+VerifyWithResult behaves like Verify, but reports the status of every
+signature of the envelope and the result of the EnvelopeVerifier's policy. If
+the envelope is not accepted after its signatures were checked, the result is
+returned along with an error wrapping ErrThresholdNotMet or
+ErrPolicyNotSatisfied.
+
+Unlike Verify, which fails if any signature is not valid base64, such
+signatures are skipped and reported with SignatureDecodeError.
+*/
+func (ev *EnvelopeVerifier) VerifyWithResult(ctx context.Context, e *Envelope) (*VerificationResult, error) {
+	result, _, err := ev.verify(ctx, e, true)
+	return result, err
+}
+
+/*
+VerifyPolicy behaves like Verify, but returns the result of each group of the
+EnvelopeVerifier's policy, explaining why the envelope was accepted or not. If
+the policy is not satisfied, the result is returned along with an error
+wrapping ErrPolicyNotSatisfied.
+*/
+func (ev *EnvelopeVerifier) VerifyPolicy(ctx context.Context, e *Envelope) (*PolicyResult, error) {
+	result, _, err := ev.verify(ctx, e, false)
+	if result == nil {
+		return nil, err
+	}
+	return result.Policy, err
+}
+
+// verify verifies e with its embedded payload. withResult selects the errors
+// of VerifyWithResult over those of Verify, see evaluatePolicy.
+func (ev *EnvelopeVerifier) verify(ctx context.Context, e *Envelope, withResult bool) (*VerificationResult, []byte, error) {
 	if e == nil {
-		return nil, nil, errors.New("cannot verify a nil envelope")
+		return nil, nil, ErrNilEnvelope
 	}
 
 	if len(e.Signatures) == 0 {
@@ -45,42 +125,150 @@ func (ev *EnvelopeVerifier) VerifyAndDecode(ctx context.Context, e *Envelope) ([
 	if err != nil {
 		return nil, nil, err
 	}
-	// Generate PAE(payloadtype, serialized body)
-	paeEnc := PAE(e.PayloadType, body)
+	// Generate PAE(payloadtype, serialized body), only copying it into a
+	// buffer for verifiers that cannot read it incrementally.
+	var paeEnc []byte
+	result, err := ev.evaluatePolicy(ctx, e, withResult, func(_, provider int, sig []byte) error {
+		v := ev.providers[provider]
+		if sv, ok := v.(StreamVerifier); ok {
+			r := &eofReader{r: NewPAEReader(e.PayloadType, bytes.NewReader(body), int64(len(body)))}
+			if err := sv.VerifyStream(ctx, r, sig); err != nil {
+				return err
+			}
+			if !r.eof {
+				return errIncompleteRead
+			}
+			return nil
+		}
 
-	// If *any* signature is found to be incorrect, it is skipped
-	var acceptedKeys []AcceptedKey
-	usedKeyids := make(map[string]string)
-	unverifiedProviders := make([]Verifier, len(ev.providers))
-	copy(unverifiedProviders, ev.providers)
-	for _, s := range e.Signatures {
+		if paeEnc == nil {
+			paeEnc = PAE(e.PayloadType, body)
+		}
+		return v.Verify(ctx, paeEnc, sig)
+	})
+	return result, body, err
+}
+
+/*
+evaluatePolicy matches the signatures of e with the verifiers of each group of
+the policy, calling verify to check the decoded signature at index sig of
+e.Signatures with the provider at index provider of ev.providers. If the
+policy is not satisfied, the result is returned along with an error.
+
+Unless withResult is set, the errors are those Verify has always returned: a
+signature that is not valid base64 fails verification, and the threshold
+error does not wrap ErrThresholdNotMet, as callers may compare it directly.
+*/
+func (ev *EnvelopeVerifier) evaluatePolicy(ctx context.Context, e *Envelope, withResult bool, verify func(sig, provider int, decodedSig []byte) error) (*VerificationResult, error) {
+	groups := ev.policy.appendGroups(nil)
+	result := &VerificationResult{
+		Signatures: make([]SignatureResult, len(e.Signatures)),
+		Policy: &PolicyResult{
+			Groups: make([]GroupResult, 0, len(groups)),
+		},
+	}
+
+	// Signatures that fail to decode are skipped by all groups.
+	sigs := make([][]byte, len(e.Signatures))
+	for i, s := range e.Signatures {
 		sig, err := b64Decode(s.Sig)
 		if err != nil {
-			return nil, nil, err
+			if !withResult {
+				return nil, err
+			}
+			result.Signatures[i] = SignatureResult{Signature: s, Status: SignatureDecodeError, Err: err}
+			continue
+		}
+		sigs[i] = sig
+		result.Signatures[i] = SignatureResult{Signature: s, Status: SignatureUnknownKeyID, Err: fmt.Errorf("%w %q", ErrUnknownKeyID, s.KeyID)}
+	}
+
+	offset := 0
+	for _, g := range groups {
+		// Sanity if with some reflect magic this happens.
+		if g.threshold <= 0 || g.threshold > len(g.verifiers) {
+			return nil, ErrInvalidThreshold
+		}
+
+		acceptedKeys := ev.matchSignatures(e, sigs, offset, len(g.verifiers), verify, result.Signatures)
+		offset += len(g.verifiers)
+
+		result.Policy.Groups = append(result.Policy.Groups, GroupResult{
+			Name:         g.name,
+			Threshold:    g.threshold,
+			AcceptedKeys: acceptedKeys,
+			Passed:       len(acceptedKeys) >= g.threshold,
+		})
+	}
+	result.AcceptedKeys = result.Policy.acceptedKeys()
+	ev.logSignatures(ctx, result.Signatures)
+
+	groupResults := make(map[*groupPolicy]*GroupResult, len(groups))
+	for i, g := range groups {
+		groupResults[g] = &result.Policy.Groups[i]
+	}
+	passed, explanation := ev.policy.evaluate(groupResults)
+	result.Policy.Passed = passed
+	if passed {
+		return result, nil
+	}
+
+	// Verifiers created by NewMultiEnvelopeVerifier have a single unnamed
+	// group.
+	if g, ok := ev.policy.(*groupPolicy); ok && g.name == "" {
+		err := fmt.Errorf("%w, Found: %d, Expected %d", ErrThresholdNotMet, len(result.AcceptedKeys), g.threshold)
+		if !withResult {
+			err = errors.New(err.Error())
 		}
+		return result, err
+	}
+	return result, fmt.Errorf("%w: %s", ErrPolicyNotSatisfied, explanation)
+}
+
+// matchSignatures matches the decoded signatures sigs of e with the count
+// providers starting at index first of ev.providers, returning the accepted
+// keys, at most one per keyid. The status of each signature in results is
+// updated if the group reached a more favorable outcome for it.
+func (ev *EnvelopeVerifier) matchSignatures(e *Envelope, sigs [][]byte, first, count int, verify func(sig, provider int, decodedSig []byte) error, results []SignatureResult) []AcceptedKey {
+	keyIDs := make([]string, count)
+	for i := range keyIDs {
+		keyIDs[i] = providerKeyID(ev.providers[first+i])
+	}
+
+	// If *any* signature is found to be incorrect, it is skipped
+	var acceptedKeys []AcceptedKey
+	// usedKeyids maps the keyids of accepted keys to their provider.
+	usedKeyids := make(map[string]int)
+	unverifiedProviders := make([]int, count)
+	for i := range unverifiedProviders {
+		unverifiedProviders[i] = first + i
+	}
+	for si, s := range e.Signatures {
+		if results[si].Status == SignatureDecodeError {
+			continue
+		}
+		sig := sigs[si]
+		result := SignatureResult{Signature: s, Status: SignatureUnknownKeyID, Err: fmt.Errorf("%w %q", ErrUnknownKeyID, s.KeyID)}
 
 		// Loop over the providers.
 		// If provider and signature include key IDs but do not match skip.
 		// If a provider recognizes the key, we exit
 		// the loop and use the result.
 		providers := unverifiedProviders
-		for i, v := range providers {
-			keyID, err := v.KeyID()
-
-			// Verifiers that do not provide a keyid will be generated one using public.
-			if err != nil || keyID == "" {
-				keyID, err = SHA256KeyID(v.Public())
-				if err != nil {
-					keyID = ""
-				}
-			}
+		for i, p := range providers {
+			v := ev.providers[p]
+			keyID := keyIDs[p-first]
 
-			if s.KeyID != "" && keyID != "" && err == nil && s.KeyID != keyID {
+			if s.KeyID != "" && keyID != "" && s.KeyID != keyID {
 				continue
 			}
 
-			err = v.Verify(ctx, paeEnc, sig)
+			err := verify(si, p, sig)
 			if err != nil {
+				if result.Status == SignatureUnknownKeyID {
+					result.Status = SignatureBad
+					result.Err = fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+				}
 				continue
 			}
 
@@ -93,26 +281,64 @@ func (ev *EnvelopeVerifier) VerifyAndDecode(ctx context.Context, e *Envelope) ([
 
 			// See https://github.com/in-toto/in-toto/pull/251
 			if _, ok := usedKeyids[keyID]; ok {
-				fmt.Printf("Found envelope signed by different subkeys of the same main key, Only one of them is counted towards the step threshold, KeyID=%s\n", keyID)
+				result = SignatureResult{Signature: s, Status: SignatureDuplicateKey, KeyID: keyID, Err: fmt.Errorf("%w: %s", ErrDuplicateKey, keyID)}
 				continue
 			}
 
-			usedKeyids[keyID] = ""
+			usedKeyids[keyID] = p
 			acceptedKeys = append(acceptedKeys, acceptedKey)
+			result = SignatureResult{Signature: s, Status: SignatureAccepted, KeyID: keyID}
 			break
 		}
+
+		// A signature whose key was already counted through another
+		// signature has no verifier left to check it, so it is checked
+		// against the verifier that accepted the key.
+		if p, ok := usedKeyids[s.KeyID]; ok && s.KeyID != "" && result.Status == SignatureUnknownKeyID {
+			if err := verify(si, p, sig); err != nil {
+				result = SignatureResult{Signature: s, Status: SignatureBad, Err: fmt.Errorf("%w: %w", ErrInvalidSignature, err)}
+			} else {
+				result = SignatureResult{Signature: s, Status: SignatureDuplicateKey, KeyID: s.KeyID, Err: fmt.Errorf("%w: %s", ErrDuplicateKey, s.KeyID)}
+			}
+		}
+
+		if result.Status.rank() > results[si].Status.rank() {
+			results[si] = result
+		}
 	}
 
-	// Sanity if with some reflect magic this happens.
-	if ev.threshold <= 0 || ev.threshold > len(ev.providers) {
-		return nil, nil, errors.New("invalid threshold")
+	return acceptedKeys
+}
+
+func (ev *EnvelopeVerifier) logSignatures(ctx context.Context, results []SignatureResult) {
+	if ev.logger == nil {
+		return
 	}
 
-	if len(usedKeyids) < ev.threshold {
-		return acceptedKeys, nil, fmt.Errorf("accepted signatures do not match threshold, Found: %d, Expected %d", len(acceptedKeys), ev.threshold)
+	for i, result := range results {
+		switch result.Status {
+		case SignatureAccepted:
+		case SignatureDuplicateKey:
+			ev.logger.InfoContext(ctx, "envelope signed by different subkeys of the same main key, only one of them is counted towards the threshold",
+				"index", i, "keyid", result.KeyID)
+		default:
+			ev.logger.DebugContext(ctx, "signature not accepted",
+				"index", i, "keyid", result.Signature.KeyID, "status", result.Status.String(), "error", result.Err)
+		}
 	}
+}
 
-	return acceptedKeys, body, nil
+// providerKeyID returns the keyid of v. Verifiers that do not provide a keyid
+// will be generated one using public, if possible.
+func providerKeyID(v Verifier) string {
+	keyID, err := v.KeyID()
+	if err != nil || keyID == "" {
+		keyID, err = SHA256KeyID(v.Public())
+		if err != nil {
+			keyID = ""
+		}
+	}
+	return keyID
 }
 
 func NewEnvelopeVerifier(v ...Verifier) (*EnvelopeVerifier, error) {
@@ -121,12 +347,43 @@ func NewEnvelopeVerifier(v ...Verifier) (*EnvelopeVerifier, error) {
 
 func NewMultiEnvelopeVerifier(threshold int, p ...Verifier) (*EnvelopeVerifier, error) {
 	if threshold <= 0 || threshold > len(p) {
-		return nil, errors.New("invalid threshold")
+		return nil, ErrInvalidThreshold
 	}
 
 	ev := EnvelopeVerifier{
+		policy: &groupPolicy{
+			threshold: threshold,
+			verifiers: p,
+		},
 		providers: p,
-		threshold: threshold,
+	}
+
+	return &ev, nil
+}
+
+/*
+NewPolicyEnvelopeVerifier creates an EnvelopeVerifier that accepts envelopes
+whose signatures satisfy policy. Verify and VerifyAndDecode return the keys
+accepted by any group of the policy, and VerifyPolicy the result of each group.
+*/
+func NewPolicyEnvelopeVerifier(policy Policy) (*EnvelopeVerifier, error) {
+	if policy == nil {
+		return nil, fmt.Errorf("%w: no policy provided", ErrInvalidPolicy)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, err
+	}
+
+	ev := EnvelopeVerifier{
+		policy: policy,
+	}
+	names := map[string]bool{}
+	for _, g := range policy.appendGroups(nil) {
+		if names[g.name] {
+			return nil, fmt.Errorf("%w: duplicate group %q", ErrInvalidPolicy, g.name)
+		}
+		names[g.name] = true
+		ev.providers = append(ev.providers, g.verifiers...)
 	}
 
 	return &ev, nil
@@ -142,6 +399,6 @@ func SHA256KeyID(pub crypto.PublicKey) (string, error) {
 	return fingerprint, nil
 }
 
-func removeIndex(v []Verifier, index int) []Verifier {
+func removeIndex[T any](v []T, index int) []T {
 	return append(v[:index], v[index+1:]...)
 }
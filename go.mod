@@ -4,6 +4,7 @@ go 1.25.0
 
 require (
 	github.com/codahale/rfc6979 v0.0.0-20141003034818-6a90f24967eb
+	github.com/miekg/pkcs11 v1.1.2
 	github.com/stretchr/testify v1.12.1
 	golang.org/x/crypto v0.55.0
 )
@@ -0,0 +1,255 @@
+package signerverifier
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+const CertificatePEM = "CERTIFICATE"
+
+var (
+	ErrInvalidCertificate          = errors.New("invalid certificate")
+	ErrCertificateIdentityMismatch = errors.New("certificate does not match identity")
+	ErrCertificateIssuerMismatch   = errors.New("certificate does not match issuer")
+)
+
+var (
+	// oidFulcioIssuer is the deprecated Fulcio OIDC issuer extension, which
+	// holds the issuer as raw bytes.
+	oidFulcioIssuer = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	// oidFulcioIssuerV2 is the Fulcio OIDC issuer extension, which holds the
+	// issuer as a DER encoded UTF8String.
+	oidFulcioIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+)
+
+// CertificateVerifier is a dsse.Verifier compliant interface to verify
+// signatures using the key of an X.509 certificate, such as a short-lived
+// certificate issued by Fulcio. The certificate is only trusted once its chain
+// has been validated and it matches the expected identity and issuer.
+type CertificateVerifier struct {
+	keyID       string
+	certificate *x509.Certificate
+	verifier    dsse.Verifier
+}
+
+// CertificateVerifierOption configures a CertificateVerifier.
+type CertificateVerifierOption func(*certificateVerifierOptions)
+
+type certificateVerifierOptions struct {
+	intermediates []*x509.Certificate
+	currentTime   time.Time
+	keyUsages     []x509.ExtKeyUsage
+	anyIdentity   bool
+}
+
+// WithIntermediates adds intermediate certificates that may be used to chain
+// the leaf certificate to a root, in addition to any bundled in
+// KeyVal.Certificate.
+func WithIntermediates(intermediates ...*x509.Certificate) CertificateVerifierOption {
+	return func(o *certificateVerifierOptions) {
+		o.intermediates = append(o.intermediates, intermediates...)
+	}
+}
+
+// WithVerificationTime sets the time at which the certificate chain must be
+// valid. Short-lived certificates are usually expired by the time signatures
+// are verified, so this should be the time the signature was created, e.g. as
+// attested by a transparency log. The current time is used by default.
+func WithVerificationTime(t time.Time) CertificateVerifierOption {
+	return func(o *certificateVerifierOptions) {
+		o.currentTime = t
+	}
+}
+
+// WithExtKeyUsages sets the extended key usages the certificate must be valid
+// for. Only code signing certificates are accepted by default.
+func WithExtKeyUsages(keyUsages ...x509.ExtKeyUsage) CertificateVerifierOption {
+	return func(o *certificateVerifierOptions) {
+		o.keyUsages = keyUsages
+	}
+}
+
+// WithAnyIdentity accepts keys that do not set KeyVal.Identity, trusting any
+// certificate that chains to the roots and, if KeyVal.Issuer is set, was
+// issued for that OIDC issuer. This is only safe if the roots exclusively
+// issue certificates to trusted signers.
+func WithAnyIdentity() CertificateVerifierOption {
+	return func(o *certificateVerifierOptions) {
+		o.anyIdentity = true
+	}
+}
+
+// NewCertificateVerifierFromSSLibKey creates a CertificateVerifier from an
+// SSLibKey whose KeyVal.Certificate holds a PEM encoded leaf certificate,
+// optionally followed by intermediate certificates. The chain is validated
+// against roots. If KeyVal.Identity is set, it must match one of the subject
+// alternative names of the certificate, and if KeyVal.Issuer is set, it must
+// match the Fulcio OIDC issuer extension. KeyVal.Identity must be set unless
+// WithAnyIdentity is passed, as an issuer alone is shared by all of its
+// users. The key's scheme determines how
+// signatures are verified with the certificate's key.
+func NewCertificateVerifierFromSSLibKey(key *SSLibKey, roots *x509.CertPool, opts ...CertificateVerifierOption) (*CertificateVerifier, error) {
+	if key == nil || len(key.KeyVal.Certificate) == 0 {
+		return nil, ErrInvalidKey
+	}
+	if roots == nil {
+		return nil, fmt.Errorf("unable to create certificate verifier: %w: no roots provided", ErrInvalidCertificate)
+	}
+
+	options := &certificateVerifierOptions{
+		keyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	if key.KeyVal.Identity == "" && !options.anyIdentity {
+		return nil, fmt.Errorf("unable to create certificate verifier: %w: no identity", ErrInvalidKey)
+	}
+
+	certificates, err := parseCertificates([]byte(key.KeyVal.Certificate))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create certificate verifier: %w", err)
+	}
+	leaf := certificates[0]
+
+	intermediates := x509.NewCertPool()
+	for _, intermediate := range append(certificates[1:], options.intermediates...) {
+		intermediates.AddCert(intermediate)
+	}
+
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   options.currentTime,
+		KeyUsages:     options.keyUsages,
+	}); err != nil {
+		return nil, fmt.Errorf("unable to create certificate verifier: %w: %w", ErrInvalidCertificate, err)
+	}
+
+	if key.KeyVal.Identity != "" && !slices.Contains(certificateIdentities(leaf), key.KeyVal.Identity) {
+		return nil, fmt.Errorf("unable to create certificate verifier: %w: %q", ErrCertificateIdentityMismatch, key.KeyVal.Identity)
+	}
+
+	if key.KeyVal.Issuer != "" {
+		issuer, err := certificateOIDCIssuer(leaf)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create certificate verifier: %w", err)
+		}
+		if issuer != key.KeyVal.Issuer {
+			return nil, fmt.Errorf("unable to create certificate verifier: %w: %q", ErrCertificateIssuerMismatch, key.KeyVal.Issuer)
+		}
+	}
+
+	certificateKey, err := newSSLibKeyFromPublicKey(leaf.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create certificate verifier: %w", err)
+	}
+	if len(key.KeyVal.Public) > 0 && key.KeyVal.Public != certificateKey.KeyVal.Public {
+		return nil, fmt.Errorf("unable to create certificate verifier: %w: certificate does not match public key", ErrInvalidKey)
+	}
+	if key.Scheme != "" {
+		certificateKey.Scheme = key.Scheme
+	}
+
+	verifier, err := NewSignerVerifierFromSSLibKey(certificateKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create certificate verifier: %w", err)
+	}
+
+	return &CertificateVerifier{
+		keyID:       key.KeyID,
+		certificate: leaf,
+		verifier:    verifier,
+	}, nil
+}
+
+// Verify verifies the `sig` value passed in against `data`.
+func (v *CertificateVerifier) Verify(ctx context.Context, data []byte, sig []byte) error {
+	return v.verifier.Verify(ctx, data, sig)
+}
+
+// KeyID returns the identifier of the key used to create the
+// CertificateVerifier instance.
+func (v *CertificateVerifier) KeyID() (string, error) {
+	return v.keyID, nil
+}
+
+// Public returns the public key of the certificate.
+func (v *CertificateVerifier) Public() crypto.PublicKey {
+	return v.certificate.PublicKey
+}
+
+// Certificate returns the validated leaf certificate.
+func (v *CertificateVerifier) Certificate() *x509.Certificate {
+	return v.certificate
+}
+
+// parseCertificates parses all PEM encoded certificates in data, in order.
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	certificates := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != CertificatePEM {
+			continue
+		}
+
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidCertificate, err)
+		}
+		certificates = append(certificates, certificate)
+	}
+
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("%w: no PEM encoded certificate found", ErrInvalidCertificate)
+	}
+	return certificates, nil
+}
+
+// certificateIdentities returns the subject alternative names of the
+// certificate.
+func certificateIdentities(certificate *x509.Certificate) []string {
+	identities := []string{}
+	identities = append(identities, certificate.EmailAddresses...)
+	for _, uri := range certificate.URIs {
+		identities = append(identities, uri.String())
+	}
+	identities = append(identities, certificate.DNSNames...)
+	return identities
+}
+
+// certificateOIDCIssuer returns the OIDC issuer recorded in a Fulcio
+// certificate.
+func certificateOIDCIssuer(certificate *x509.Certificate) (string, error) {
+	for _, extension := range certificate.Extensions {
+		if extension.Id.Equal(oidFulcioIssuerV2) {
+			var issuer string
+			if _, err := asn1.UnmarshalWithParams(extension.Value, &issuer, "utf8"); err != nil {
+				return "", fmt.Errorf("%w: %w", ErrInvalidCertificate, err)
+			}
+			return issuer, nil
+		}
+	}
+
+	for _, extension := range certificate.Extensions {
+		if extension.Id.Equal(oidFulcioIssuer) {
+			return string(extension.Value), nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: no OIDC issuer extension", ErrCertificateIssuerMismatch)
+}
@@ -0,0 +1,416 @@
+package signerverifier
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSigningTime = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+type testCertificateAuthority struct {
+	root             *x509.Certificate
+	roots            *x509.CertPool
+	intermediate     *x509.Certificate
+	intermediateKey  crypto.Signer
+	intermediatePEMs string
+}
+
+func createTestCertificate(t *testing.T, template, parent *x509.Certificate, public crypto.PublicKey, parentKey crypto.Signer) *x509.Certificate {
+	t.Helper()
+
+	if template.SerialNumber == nil {
+		serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.SerialNumber = serial
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, public, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate
+}
+
+func newTestCertificateAuthority(t *testing.T) *testCertificateAuthority {
+	t.Helper()
+
+	rootKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTemplate := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test root"},
+		NotBefore:             testSigningTime.AddDate(-1, 0, 0),
+		NotAfter:              testSigningTime.AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	root := createTestCertificate(t, rootTemplate, rootTemplate, rootKey.Public(), rootKey)
+
+	intermediateKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	intermediate := createTestCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test intermediate"},
+		NotBefore:             testSigningTime.AddDate(-1, 0, 0),
+		NotAfter:              testSigningTime.AddDate(5, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, root, intermediateKey.Public(), rootKey)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+
+	return &testCertificateAuthority{
+		root:             root,
+		roots:            roots,
+		intermediate:     intermediate,
+		intermediateKey:  intermediateKey,
+		intermediatePEMs: string(generatePEMBlock(intermediate.Raw, CertificatePEM)),
+	}
+}
+
+// testLeafTemplate returns the template of a short-lived leaf certificate in
+// the style of Fulcio.
+func testLeafTemplate(t *testing.T) *x509.Certificate {
+	t.Helper()
+
+	issuer, err := asn1.MarshalWithParams("https://accounts.example.com", "utf8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri, err := url.Parse("https://github.com/example/repo/.github/workflows/release.yml@refs/heads/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &x509.Certificate{
+		NotBefore:      testSigningTime.Add(-time.Minute),
+		NotAfter:       testSigningTime.Add(10 * time.Minute),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses: []string{"user@example.com"},
+		URIs:           []*url.URL{uri},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidFulcioIssuerV2, Value: issuer},
+		},
+	}
+}
+
+// issue creates a leaf certificate from testLeafTemplate and returns it in PEM
+// encoding along with the SSLibKey of the leaf key.
+func (ca *testCertificateAuthority) issue(t *testing.T, modify func(template *x509.Certificate)) (string, *SSLibKey) {
+	t.Helper()
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := testLeafTemplate(t)
+	if modify != nil {
+		modify(template)
+	}
+
+	leaf := createTestCertificate(t, template, ca.intermediate, leafKey.Public(), ca.intermediateKey)
+
+	key, err := newSSLibKeyFromPrivateKey(leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(generatePEMBlock(leaf.Raw, CertificatePEM)), key
+}
+
+func TestCertificateVerifier(t *testing.T) {
+	ca := newTestCertificateAuthority(t)
+	message := []byte("test message")
+
+	leafPEM, leafKey := ca.issue(t, nil)
+	signer, err := NewSignerVerifierFromSSLibKey(leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signature, err := signer.Sign(context.Background(), message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newKey := func(certificate string) *SSLibKey {
+		return &SSLibKey{
+			KeyType: ECDSAKeyType,
+			Scheme:  ECDSAKeyScheme,
+			KeyID:   "fulcio-keyid",
+			KeyVal: KeyVal{
+				Certificate: certificate,
+				Identity:    "user@example.com",
+				Issuer:      "https://accounts.example.com",
+			},
+		}
+	}
+
+	t.Run("certificate chain in key", func(t *testing.T) {
+		key := newKey(leafPEM + ca.intermediatePEMs)
+		assert.Nil(t, key.Validate())
+
+		verifier, err := NewCertificateVerifierFromSSLibKey(key, ca.roots, WithVerificationTime(testSigningTime))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keyID, err := verifier.KeyID()
+		assert.Nil(t, err)
+		assert.Equal(t, "fulcio-keyid", keyID)
+		assert.Equal(t, signer.Public(), verifier.Public())
+		assert.Equal(t, []string{"user@example.com"}, verifier.Certificate().EmailAddresses)
+
+		assert.Nil(t, verifier.Verify(context.Background(), message, signature))
+		assert.ErrorIs(t, verifier.Verify(context.Background(), []byte("another message"), signature), ErrSignatureVerificationFailed)
+	})
+
+	t.Run("intermediates as option", func(t *testing.T) {
+		verifier, err := NewCertificateVerifierFromSSLibKey(newKey(leafPEM), ca.roots, WithVerificationTime(testSigningTime), WithIntermediates(ca.intermediate))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, verifier.Verify(context.Background(), message, signature))
+	})
+
+	t.Run("URI identity", func(t *testing.T) {
+		key := newKey(leafPEM + ca.intermediatePEMs)
+		key.KeyVal.Identity = "https://github.com/example/repo/.github/workflows/release.yml@refs/heads/main"
+
+		_, err := NewCertificateVerifierFromSSLibKey(key, ca.roots, WithVerificationTime(testSigningTime))
+		assert.Nil(t, err)
+	})
+
+	t.Run("public key in key", func(t *testing.T) {
+		key := newKey(leafPEM + ca.intermediatePEMs)
+		key.KeyVal.Public = leafKey.KeyVal.Public
+
+		_, err := NewCertificateVerifierFromSSLibKey(key, ca.roots, WithVerificationTime(testSigningTime))
+		assert.Nil(t, err)
+
+		otherKey, err := LoadKey(ecdsaPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.KeyVal.Public = otherKey.KeyVal.Public
+		_, err = NewCertificateVerifierFromSSLibKey(key, ca.roots, WithVerificationTime(testSigningTime))
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	t.Run("legacy issuer extension", func(t *testing.T) {
+		legacyPEM, _ := ca.issue(t, func(template *x509.Certificate) {
+			template.ExtraExtensions = []pkix.Extension{
+				{Id: oidFulcioIssuer, Value: []byte("https://accounts.example.com")},
+			}
+		})
+
+		_, err := NewCertificateVerifierFromSSLibKey(newKey(legacyPEM+ca.intermediatePEMs), ca.roots, WithVerificationTime(testSigningTime))
+		assert.Nil(t, err)
+	})
+
+	t.Run("with DSSE envelope", func(t *testing.T) {
+		es, err := dsse.NewEnvelopeSigner(signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, err := es.SignPayload(context.Background(), "application/vnd.dsse+json", message)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The envelope verifier matches signatures by keyid.
+		key := newKey(leafPEM + ca.intermediatePEMs)
+		key.KeyID = leafKey.KeyID
+
+		verifier, err := NewCertificateVerifierFromSSLibKey(key, ca.roots, WithVerificationTime(testSigningTime))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev, err := dsse.NewEnvelopeVerifier(verifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ev.Verify(context.Background(), env)
+		assert.Nil(t, err)
+	})
+
+	serverAuthPEM, _ := ca.issue(t, func(template *x509.Certificate) {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	})
+	noIssuerPEM, _ := ca.issue(t, func(template *x509.Certificate) {
+		template.ExtraExtensions = nil
+	})
+	otherCA := newTestCertificateAuthority(t)
+
+	errorTests := map[string]struct {
+		key         *SSLibKey
+		roots       *x509.CertPool
+		opts        []CertificateVerifierOption
+		expectedErr error
+	}{
+		"expired certificate": {
+			key:         newKey(leafPEM + ca.intermediatePEMs),
+			roots:       ca.roots,
+			expectedErr: ErrInvalidCertificate,
+		},
+		"certificate not yet valid": {
+			key:         newKey(leafPEM + ca.intermediatePEMs),
+			roots:       ca.roots,
+			opts:        []CertificateVerifierOption{WithVerificationTime(testSigningTime.Add(-time.Hour))},
+			expectedErr: ErrInvalidCertificate,
+		},
+		"missing intermediate": {
+			key:         newKey(leafPEM),
+			roots:       ca.roots,
+			opts:        []CertificateVerifierOption{WithVerificationTime(testSigningTime)},
+			expectedErr: ErrInvalidCertificate,
+		},
+		"untrusted root": {
+			key:         newKey(leafPEM + ca.intermediatePEMs),
+			roots:       otherCA.roots,
+			opts:        []CertificateVerifierOption{WithVerificationTime(testSigningTime)},
+			expectedErr: ErrInvalidCertificate,
+		},
+		"no roots": {
+			key:         newKey(leafPEM + ca.intermediatePEMs),
+			expectedErr: ErrInvalidCertificate,
+		},
+		"wrong extended key usage": {
+			key:         newKey(serverAuthPEM + ca.intermediatePEMs),
+			roots:       ca.roots,
+			opts:        []CertificateVerifierOption{WithVerificationTime(testSigningTime)},
+			expectedErr: ErrInvalidCertificate,
+		},
+		"malformed certificate": {
+			key:         newKey(strings.Replace(leafPEM, "MII", "AAA", 1)),
+			roots:       ca.roots,
+			expectedErr: ErrInvalidCertificate,
+		},
+		"identity mismatch": {
+			key: func() *SSLibKey {
+				key := newKey(leafPEM + ca.intermediatePEMs)
+				key.KeyVal.Identity = "attacker@example.com"
+				return key
+			}(),
+			roots:       ca.roots,
+			opts:        []CertificateVerifierOption{WithVerificationTime(testSigningTime)},
+			expectedErr: ErrCertificateIdentityMismatch,
+		},
+		"issuer mismatch": {
+			key: func() *SSLibKey {
+				key := newKey(leafPEM + ca.intermediatePEMs)
+				key.KeyVal.Issuer = "https://attacker.example.com"
+				return key
+			}(),
+			roots:       ca.roots,
+			opts:        []CertificateVerifierOption{WithVerificationTime(testSigningTime)},
+			expectedErr: ErrCertificateIssuerMismatch,
+		},
+		"missing issuer extension": {
+			key:         newKey(noIssuerPEM + ca.intermediatePEMs),
+			roots:       ca.roots,
+			opts:        []CertificateVerifierOption{WithVerificationTime(testSigningTime)},
+			expectedErr: ErrCertificateIssuerMismatch,
+		},
+		"missing certificate": {
+			key:         newKey(""),
+			roots:       ca.roots,
+			expectedErr: ErrInvalidKey,
+		},
+		"no identity or issuer": {
+			key: func() *SSLibKey {
+				key := newKey(leafPEM + ca.intermediatePEMs)
+				key.KeyVal.Identity = ""
+				key.KeyVal.Issuer = ""
+				return key
+			}(),
+			roots:       ca.roots,
+			opts:        []CertificateVerifierOption{WithVerificationTime(testSigningTime)},
+			expectedErr: ErrInvalidKey,
+		},
+		"issuer only": {
+			key: func() *SSLibKey {
+				key := newKey(leafPEM + ca.intermediatePEMs)
+				key.KeyVal.Identity = ""
+				return key
+			}(),
+			roots:       ca.roots,
+			opts:        []CertificateVerifierOption{WithVerificationTime(testSigningTime)},
+			expectedErr: ErrInvalidKey,
+		},
+		"issuer mismatch with any identity": {
+			key: func() *SSLibKey {
+				key := newKey(leafPEM + ca.intermediatePEMs)
+				key.KeyVal.Identity = ""
+				key.KeyVal.Issuer = "https://other.example.com"
+				return key
+			}(),
+			roots:       ca.roots,
+			opts:        []CertificateVerifierOption{WithVerificationTime(testSigningTime), WithAnyIdentity()},
+			expectedErr: ErrCertificateIssuerMismatch,
+		},
+	}
+
+	for name, test := range errorTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewCertificateVerifierFromSSLibKey(test.key, test.roots, test.opts...)
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+
+	t.Run("any extended key usage", func(t *testing.T) {
+		_, err := NewCertificateVerifierFromSSLibKey(newKey(serverAuthPEM+ca.intermediatePEMs), ca.roots, WithVerificationTime(testSigningTime), WithExtKeyUsages(x509.ExtKeyUsageAny))
+		assert.Nil(t, err)
+	})
+
+	t.Run("any identity", func(t *testing.T) {
+		key := newKey(leafPEM + ca.intermediatePEMs)
+		key.KeyVal.Identity = ""
+		key.KeyVal.Issuer = ""
+
+		verifier, err := NewCertificateVerifierFromSSLibKey(key, ca.roots, WithVerificationTime(testSigningTime), WithAnyIdentity())
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, verifier.Verify(context.Background(), message, signature))
+
+		// The issuer is still checked if set.
+		key.KeyVal.Issuer = "https://accounts.example.com"
+		_, err = NewCertificateVerifierFromSSLibKey(key, ca.roots, WithVerificationTime(testSigningTime), WithAnyIdentity())
+		assert.Nil(t, err)
+	})
+
+	t.Run("identity only", func(t *testing.T) {
+		key := newKey(leafPEM + ca.intermediatePEMs)
+		key.KeyVal.Issuer = ""
+
+		_, err := NewCertificateVerifierFromSSLibKey(key, ca.roots, WithVerificationTime(testSigningTime))
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,106 @@
+package signerverifier
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+// CryptoSignerVerifier is a dsse.SignerVerifier compliant interface to sign
+// and verify signatures using any crypto.Signer. This allows keys that are not
+// directly accessible, such as keys held by an OS keystore, a hardware token,
+// or an agent, to be used with the scheme of an SSLibKey.
+type CryptoSignerVerifier struct {
+	keyID    string
+	signer   crypto.Signer
+	opts     crypto.SignerOpts
+	verifier dsse.Verifier
+}
+
+// NewCryptoSignerVerifier creates a CryptoSignerVerifier from a crypto.Signer
+// and the SSLibKey describing its public portion. The hashing and padding
+// applied before calling the signer are derived from the key's scheme.
+func NewCryptoSignerVerifier(signer crypto.Signer, key *SSLibKey) (*CryptoSignerVerifier, error) {
+	if signer == nil || key == nil {
+		return nil, ErrInvalidKey
+	}
+
+	opts, err := signerOptsForScheme(key.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create crypto signerverifier: %w", err)
+	}
+
+	// The verifier only needs the public portion, and the signer is the
+	// source of truth for the private portion.
+	publicKey := *key
+	publicKey.KeyVal.Private = ""
+	verifier, err := NewSignerVerifierFromSSLibKey(&publicKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create crypto signerverifier: %w", err)
+	}
+
+	public, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(verifier.Public()) {
+		return nil, fmt.Errorf("unable to create crypto signerverifier: %w: signer does not match public key", ErrInvalidKey)
+	}
+
+	return &CryptoSignerVerifier{
+		keyID:    key.KeyID,
+		signer:   signer,
+		opts:     opts,
+		verifier: verifier,
+	}, nil
+}
+
+// Sign creates a signature for `data`.
+func (sv *CryptoSignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
+	digest := data
+	if hash := sv.opts.HashFunc(); hash != 0 {
+		digest = hashBeforeSigning(data, hash.New())
+	}
+
+	return sv.signer.Sign(rand.Reader, digest, sv.opts)
+}
+
+// Verify verifies the `sig` value passed in against `data`.
+func (sv *CryptoSignerVerifier) Verify(ctx context.Context, data []byte, sig []byte) error {
+	return sv.verifier.Verify(ctx, data, sig)
+}
+
+// KeyID returns the identifier of the key used to create the
+// CryptoSignerVerifier instance.
+func (sv *CryptoSignerVerifier) KeyID() (string, error) {
+	return sv.keyID, nil
+}
+
+// Public returns the public portion of the key used to create the
+// CryptoSignerVerifier instance.
+func (sv *CryptoSignerVerifier) Public() crypto.PublicKey {
+	return sv.signer.Public()
+}
+
+// signerOptsForScheme returns the crypto.SignerOpts a crypto.Signer needs to
+// create signatures following the scheme. A zero hash function indicates the
+// signer is passed the full message rather than a digest.
+func signerOptsForScheme(scheme string) (crypto.SignerOpts, error) {
+	if scheme == ED25519KeyType || isMLDSAKeyType(scheme) {
+		return crypto.Hash(0), nil
+	}
+
+	if s, ok := ecdsaSchemes[scheme]; ok {
+		return s.hash, nil
+	}
+
+	if s, ok := rsaSchemes[scheme]; ok {
+		if s.pss {
+			return &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: s.hash}, nil
+		}
+		return s.hash, nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrUnknownScheme, scheme)
+}
@@ -0,0 +1,183 @@
+package signerverifier
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+)
+
+// opaqueSigner hides the concrete key type behind crypto.Signer, like an
+// external key backend would.
+type opaqueSigner struct {
+	signer crypto.Signer
+	opts   crypto.SignerOpts
+}
+
+func (s *opaqueSigner) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+func (s *opaqueSigner) Sign(r io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.opts = opts
+	return s.signer.Sign(r, digest, opts)
+}
+
+func loadPrivateCryptoKey(t *testing.T, key *SSLibKey) crypto.Signer {
+	t.Helper()
+
+	privatePEM, err := key.MarshalPrivateKeyPEM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(privatePEM)
+	private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return private.(crypto.Signer)
+}
+
+func TestCryptoSignerVerifier(t *testing.T) {
+	tests := map[string]struct {
+		keyBytes []byte
+		scheme   string
+	}{
+		"ED25519 key": {
+			keyBytes: ed25519PrivateKey,
+			scheme:   ED25519KeyType,
+		},
+		"ECDSA key": {
+			keyBytes: ecdsaPrivateKey,
+			scheme:   ECDSAKeyScheme,
+		},
+		"ECDSA P-384 key": {
+			keyBytes: ecdsaP384PrivateKey,
+			scheme:   ECDSAP384KeyScheme,
+		},
+		"RSA-PSS key": {
+			keyBytes: rsaPrivateKey,
+			scheme:   RSAKeyScheme,
+		},
+		"RSA-PSS SHA-512 key": {
+			keyBytes: rsaPrivateKey,
+			scheme:   RSAPSSSHA512KeyScheme,
+		},
+		"RSA PKCS#1 v1.5 key": {
+			keyBytes: rsaPrivateKey,
+			scheme:   RSAPKCS1v15SHA384KeyScheme,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, err := LoadKey(test.keyBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key.Scheme = test.scheme
+
+			signer := &opaqueSigner{signer: loadPrivateCryptoKey(t, key)}
+			publicKey := *key
+			publicKey.KeyVal.Private = ""
+
+			sv, err := NewCryptoSignerVerifier(signer, &publicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			keyID, err := sv.KeyID()
+			assert.Nil(t, err)
+			assert.Equal(t, key.KeyID, keyID)
+			assert.Equal(t, signer.Public(), sv.Public())
+
+			message := []byte("test message")
+			signature, err := sv.Sign(context.Background(), message)
+			assert.Nil(t, err)
+			assert.NotNil(t, signer.opts)
+
+			// Signatures must be interchangeable with the native
+			// signerverifiers for the same scheme.
+			verifier, err := NewSignerVerifierFromSSLibKey(&publicKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Nil(t, verifier.Verify(context.Background(), message, signature))
+
+			native, err := NewSignerVerifierFromSSLibKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			nativeSignature, err := native.Sign(context.Background(), message)
+			assert.Nil(t, err)
+			assert.Nil(t, sv.Verify(context.Background(), message, nativeSignature))
+		})
+	}
+
+	t.Run("with DSSE envelope", func(t *testing.T) {
+		key, err := LoadKey(ecdsaPrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sv, err := NewCryptoSignerVerifier(loadPrivateCryptoKey(t, key), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		es, err := dsse.NewEnvelopeSigner(sv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, err := es.SignPayload(context.Background(), "application/vnd.dsse+json", []byte("test message"))
+		assert.Nil(t, err)
+
+		ev, err := dsse.NewEnvelopeVerifier(sv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		acceptedKeys, err := ev.Verify(context.Background(), env)
+		assert.Nil(t, err)
+		assert.Equal(t, key.KeyID, acceptedKeys[0].KeyID)
+	})
+
+	t.Run("signer does not match key", func(t *testing.T) {
+		key, err := LoadKey(ecdsaPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewCryptoSignerVerifier(private, key)
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+
+	t.Run("unknown scheme", func(t *testing.T) {
+		key, err := LoadKey(ed25519PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.Scheme = "ed448"
+
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewCryptoSignerVerifier(private, key)
+		assert.ErrorIs(t, err, ErrUnknownScheme)
+	})
+}
@@ -4,44 +4,94 @@ import (
 	"context"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/sha256"
-	"crypto/sha512"
+	_ "crypto/sha256" // register hash functions used by ecdsaSchemes
+	_ "crypto/sha512"
+	"encoding/asn1"
 	"fmt"
+	"io"
+	"math/big"
 	"os"
+
+	"github.com/codahale/rfc6979"
 )
 
 const (
-	ECDSAKeyType   = "ecdsa"
-	ECDSAKeyScheme = "ecdsa-sha2-nistp256"
+	ECDSAKeyType       = "ecdsa"
+	ECDSAKeyScheme     = "ecdsa-sha2-nistp256"
+	ECDSAP384KeyScheme = "ecdsa-sha2-nistp384"
+	ECDSAP521KeyScheme = "ecdsa-sha2-nistp521"
 )
 
+// ecdsaScheme captures the curve and hash algorithm mandated by an ECDSA
+// scheme.
+type ecdsaScheme struct {
+	curve elliptic.Curve
+	hash  crypto.Hash
+}
+
+var ecdsaSchemes = map[string]ecdsaScheme{
+	ECDSAKeyScheme:     {curve: elliptic.P256(), hash: crypto.SHA256},
+	ECDSAP384KeyScheme: {curve: elliptic.P384(), hash: crypto.SHA384},
+	ECDSAP521KeyScheme: {curve: elliptic.P521(), hash: crypto.SHA512},
+}
+
 // ECDSASignerVerifier is a dsse.SignerVerifier compliant interface to sign and
 // verify signatures using ECDSA keys.
 type ECDSASignerVerifier struct {
-	keyID     string
-	curveSize int
-	private   *ecdsa.PrivateKey
-	public    *ecdsa.PublicKey
+	keyID         string
+	hash          crypto.Hash
+	deterministic bool
+	private       *ecdsa.PrivateKey
+	public        *ecdsa.PublicKey
+}
+
+// ECDSASignerVerifierOption configures optional behavior of an
+// ECDSASignerVerifier.
+type ECDSASignerVerifierOption func(*ECDSASignerVerifier)
+
+// WithDeterministicSignatures makes the ECDSASignerVerifier derive the nonce
+// from the private key and the message as described in RFC 6979, instead of
+// using randomness. The same key and message then always produce the same
+// signature, which is useful for reproducible builds and golden files.
+func WithDeterministicSignatures() ECDSASignerVerifierOption {
+	return func(sv *ECDSASignerVerifier) {
+		sv.deterministic = true
+	}
 }
 
 // NewECDSASignerVerifierFromSSLibKey creates an ECDSASignerVerifier from an
-// SSLibKey.
-func NewECDSASignerVerifierFromSSLibKey(key *SSLibKey) (*ECDSASignerVerifier, error) {
+// SSLibKey. The key's scheme selects the hash algorithm and must match the
+// curve of the key.
+func NewECDSASignerVerifierFromSSLibKey(key *SSLibKey, opts ...ECDSASignerVerifierOption) (*ECDSASignerVerifier, error) {
 	if len(key.KeyVal.Public) == 0 {
 		return nil, ErrInvalidKey
 	}
 
+	scheme, ok := ecdsaSchemes[key.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unable to create ECDSA signerverifier: %w: %q", ErrUnknownScheme, key.Scheme)
+	}
+
 	_, publicParsedKey, err := decodeAndParsePEM([]byte(key.KeyVal.Public))
 	if err != nil {
 		return nil, fmt.Errorf("unable to create ECDSA signerverifier: %w", err)
 	}
 
+	public, ok := publicParsedKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unable to create ECDSA signerverifier: %w", ErrUnknownKeyType)
+	}
+	if public.Curve != scheme.curve {
+		return nil, fmt.Errorf("unable to create ECDSA signerverifier: %w: scheme %q used with curve %s", ErrSchemeMismatch, key.Scheme, public.Params().Name)
+	}
+
 	sv := &ECDSASignerVerifier{
-		keyID:     key.KeyID,
-		curveSize: publicParsedKey.(*ecdsa.PublicKey).Params().BitSize,
-		public:    publicParsedKey.(*ecdsa.PublicKey),
-		private:   nil,
+		keyID:   key.KeyID,
+		hash:    scheme.hash,
+		public:  public,
+		private: nil,
 	}
 
 	if len(key.KeyVal.Private) > 0 {
@@ -50,27 +100,82 @@ func NewECDSASignerVerifierFromSSLibKey(key *SSLibKey) (*ECDSASignerVerifier, er
 			return nil, fmt.Errorf("unable to create ECDSA signerverifier: %w", err)
 		}
 
-		sv.private = privateParsedKey.(*ecdsa.PrivateKey)
+		private, ok := privateParsedKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unable to create ECDSA signerverifier: %w", ErrUnknownKeyType)
+		}
+		sv.private = private
+	}
+
+	for _, opt := range opts {
+		opt(sv)
 	}
 
 	return sv, nil
 }
 
+// GenerateECDSAKey creates a new ECDSA key on the given curve and returns it as
+// an SSLibKey with both the public and private portions populated.
+func GenerateECDSAKey(curve elliptic.Curve) (*SSLibKey, error) {
+	private, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate ECDSA key: %w", err)
+	}
+
+	return newSSLibKeyFromPrivateKey(private)
+}
+
 // Sign creates a signature for `data`.
 func (sv *ECDSASignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
 	if sv.private == nil {
 		return nil, ErrNotPrivateKey
 	}
 
-	hashedData := getECDSAHashedData(data, sv.curveSize)
+	return sv.signDigest(hashBeforeSigning(data, sv.hash.New()))
+}
+
+// SignStream creates a signature for the data read from `r`, hashing it
+// incrementally. It implements dsse.StreamSigner.
+func (sv *ECDSASignerVerifier) SignStream(_ context.Context, r io.Reader) ([]byte, error) {
+	if sv.private == nil {
+		return nil, ErrNotPrivateKey
+	}
+
+	hashedData, err := hashReaderBeforeSigning(r, sv.hash.New())
+	if err != nil {
+		return nil, err
+	}
+	return sv.signDigest(hashedData)
+}
+
+func (sv *ECDSASignerVerifier) signDigest(hashedData []byte) ([]byte, error) {
+	if sv.deterministic {
+		r, s, err := rfc6979.SignECDSA(sv.private, hashedData, sv.hash.New)
+		if err != nil {
+			return nil, err
+		}
+		return asn1.Marshal(ecdsaSignature{R: r, S: s})
+	}
 
 	return ecdsa.SignASN1(rand.Reader, sv.private, hashedData)
 }
 
 // Verify verifies the `sig` value passed in against `data`.
 func (sv *ECDSASignerVerifier) Verify(_ context.Context, data []byte, sig []byte) error {
-	hashedData := getECDSAHashedData(data, sv.curveSize)
+	return sv.verifyDigest(hashBeforeSigning(data, sv.hash.New()), sig)
+}
+
+// VerifyStream verifies the `sig` value passed in against the data read from
+// `r`, hashing it incrementally. It implements dsse.StreamVerifier.
+func (sv *ECDSASignerVerifier) VerifyStream(_ context.Context, r io.Reader, sig []byte) error {
+	hashedData, err := hashReaderBeforeSigning(r, sv.hash.New())
+	if err != nil {
+		return err
+	}
+	return sv.verifyDigest(hashedData, sig)
+}
 
+func (sv *ECDSASignerVerifier) verifyDigest(hashedData, sig []byte) error {
 	if ok := ecdsa.VerifyASN1(sv.public, hashedData, sig); !ok {
 		return ErrSignatureVerificationFailed
 	}
@@ -105,14 +210,17 @@ func LoadECDSAKeyFromFile(path string) (*SSLibKey, error) {
 	return LoadKeyFromSSLibBytes(contents)
 }
 
-func getECDSAHashedData(data []byte, curveSize int) []byte {
-	switch {
-	case curveSize <= 256:
-		return hashBeforeSigning(data, sha256.New())
-	case 256 < curveSize && curveSize <= 384:
-		return hashBeforeSigning(data, sha512.New384())
-	case curveSize > 384:
-		return hashBeforeSigning(data, sha512.New())
+// ecdsaSignature is the ASN.1 structure of an ECDSA signature.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// ecdsaSchemeForCurve returns the ECDSA scheme that uses the given curve.
+func ecdsaSchemeForCurve(curve elliptic.Curve) (string, error) {
+	for name, scheme := range ecdsaSchemes {
+		if scheme.curve == curve {
+			return name, nil
+		}
 	}
-	return []byte{}
+	return "", fmt.Errorf("%w: no ECDSA scheme for curve %s", ErrUnknownScheme, curve.Params().Name)
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto"
 	"crypto/ed25519"
+	"crypto/rand"
 	"encoding/hex"
 	"fmt"
 	"os"
@@ -12,7 +13,9 @@ import (
 const ED25519KeyType = "ed25519"
 
 // ED25519SignerVerifier is a dsse.SignerVerifier compliant interface to sign
-// and verify signatures using ED25519 keys.
+// and verify signatures using ED25519 keys. It does not implement
+// dsse.StreamSigner, as Ed25519 hashes the full message more than once and
+// cannot sign a pre-hashed message without changing the signature scheme.
 type ED25519SignerVerifier struct {
 	keyID   string
 	private ed25519.PrivateKey
@@ -56,6 +59,17 @@ func NewED25519SignerVerifierFromSSLibKey(key *SSLibKey) (*ED25519SignerVerifier
 	}, nil
 }
 
+// GenerateED25519Key creates a new ED25519 key and returns it as an SSLibKey
+// with both the public and private portions populated.
+func GenerateED25519Key() (*SSLibKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate ED25519 key: %w", err)
+	}
+
+	return newSSLibKeyFromPrivateKey(private)
+}
+
 // Sign creates a signature for `data`.
 func (sv *ED25519SignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
 	if len(sv.private) == 0 {
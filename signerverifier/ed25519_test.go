@@ -296,3 +296,26 @@ func TestED25519SignerVerifierWithMetablockFileAndPEMKey(t *testing.T) {
 	err = sv.Verify(context.Background(), encodedBytes, decodedSig)
 	assert.Nil(t, err)
 }
+
+func TestGenerateED25519Key(t *testing.T) {
+	key, err := GenerateED25519Key()
+	assert.Nil(t, err)
+
+	assert.Equal(t, ED25519KeyType, key.KeyType)
+	assert.Equal(t, ED25519KeyType, key.Scheme)
+	assert.Equal(t, KeyIDHashAlgorithms, key.KeyIDHashAlgorithms)
+	assert.Len(t, hexDecode(t, key.KeyVal.Public), ed25519.PublicKeySize)
+	assert.Len(t, hexDecode(t, key.KeyVal.Private), ed25519.PrivateKeySize)
+
+	expectedKeyID, err := calculateKeyID(key)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedKeyID, key.KeyID)
+
+	sv, err := NewED25519SignerVerifierFromSSLibKey(key)
+	assert.Nil(t, err)
+
+	message := []byte("test message")
+	signature, err := sv.Sign(context.Background(), message)
+	assert.Nil(t, err)
+	assert.Nil(t, sv.Verify(context.Background(), message, signature))
+}
@@ -0,0 +1,429 @@
+package signerverifier
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	JWKKeyTypeOKP = "OKP"
+	JWKKeyTypeEC  = "EC"
+	JWKKeyTypeRSA = "RSA"
+)
+
+var ErrInvalidJWK = errors.New("invalid JWK")
+
+// jwkCurves maps the "crv" values of RFC 7518 and RFC 8037 to curves.
+var jwkCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// jwkAlgorithms maps the JWS "alg" values of RFC 7518 and RFC 8037 to
+// securesystemslib schemes.
+var jwkAlgorithms = map[string]string{
+	"EdDSA": ED25519KeyType,
+	"ES256": ECDSAKeyScheme,
+	"ES384": ECDSAP384KeyScheme,
+	"ES512": ECDSAP521KeyScheme,
+	"PS256": RSAKeyScheme,
+	"PS384": RSAPSSSHA384KeyScheme,
+	"PS512": RSAPSSSHA512KeyScheme,
+	"RS256": RSAPKCS1v15SHA256KeyScheme,
+	"RS384": RSAPKCS1v15SHA384KeyScheme,
+	"RS512": RSAPKCS1v15SHA512KeyScheme,
+}
+
+// JWK is a JSON Web Key as defined in RFC 7517. Only the members needed to
+// represent ED25519, ECDSA, and RSA signing keys are supported. All key
+// material is base64url encoded without padding.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	D         string `json:"d,omitempty"`
+	P         string `json:"p,omitempty"`
+	Q         string `json:"q,omitempty"`
+	DP        string `json:"dp,omitempty"`
+	DQ        string `json:"dq,omitempty"`
+	QI        string `json:"qi,omitempty"`
+}
+
+// JWKSet is a JSON Web Key Set as defined in RFC 7517.
+type JWKSet struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// LoadKeyFromJWK returns an SSLibKey object when provided a JSON encoded JWK.
+// See JWK.SSLibKey for how the key is converted.
+func LoadKeyFromJWK(data []byte) (*SSLibKey, error) {
+	jwk := &JWK{}
+	if err := json.Unmarshal(data, jwk); err != nil {
+		return nil, fmt.Errorf("unable to load JWK: %w", err)
+	}
+
+	return jwk.SSLibKey()
+}
+
+// LoadKeysFromJWKSet returns an SSLibKey object for each signing key in a JSON
+// encoded JWK Set. As recommended by RFC 7517, keys of unsupported types are
+// ignored, as are keys intended for encryption.
+func LoadKeysFromJWKSet(data []byte) ([]*SSLibKey, error) {
+	set := &JWKSet{}
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("unable to load JWK Set: %w", err)
+	}
+	if set.Keys == nil {
+		return nil, fmt.Errorf("unable to load JWK Set: %w: missing keys", ErrInvalidJWK)
+	}
+
+	keys := []*SSLibKey{}
+	for _, jwk := range set.Keys {
+		if jwk == nil || jwk.Use == "enc" {
+			continue
+		}
+
+		key, err := jwk.SSLibKey()
+		if errors.Is(err, ErrUnknownKeyType) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to load JWK Set: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// SSLibKey converts the JWK into an SSLibKey. The private portion is included
+// if the JWK holds one. The scheme is derived from the "alg" member if
+// present, otherwise the defaults of LoadKey apply. The keyid is always
+// computed the securesystemslib way rather than taken from the "kid" member,
+// so that it matches the keyid of the same key loaded from any other format.
+func (j *JWK) SSLibKey() (*SSLibKey, error) {
+	public, private, err := j.cryptoKeys()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load JWK: %w", err)
+	}
+
+	var key *SSLibKey
+	if private != nil {
+		key, err = newSSLibKeyFromPrivateKey(private)
+	} else {
+		key, err = newSSLibKeyFromPublicKey(public)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to load JWK: %w", err)
+	}
+
+	if j.Algorithm == "" {
+		return key, nil
+	}
+
+	scheme, ok := jwkAlgorithms[j.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unable to load JWK: %w: %q", ErrUnknownScheme, j.Algorithm)
+	}
+	if _, isRSAScheme := rsaSchemes[scheme]; !(key.KeyType == RSAKeyType && isRSAScheme) && scheme != key.Scheme {
+		return nil, fmt.Errorf("unable to load JWK: %w: %q", ErrSchemeMismatch, j.Algorithm)
+	}
+
+	key.Scheme = scheme
+	keyID, err := calculateKeyID(key)
+	if err != nil {
+		return nil, err
+	}
+	key.KeyID = keyID
+
+	return key, nil
+}
+
+// Thumbprint returns the base64url encoded SHA-256 JWK Thumbprint of the key,
+// as defined in RFC 7638.
+func (j *JWK) Thumbprint() (string, error) {
+	var members map[string]string
+	switch j.KeyType {
+	case JWKKeyTypeOKP, JWKKeyTypeEC:
+		members = map[string]string{"crv": j.Curve, "kty": j.KeyType, "x": j.X}
+		if j.KeyType == JWKKeyTypeEC {
+			members["y"] = j.Y
+		}
+	case JWKKeyTypeRSA:
+		members = map[string]string{"e": j.E, "kty": j.KeyType, "n": j.N}
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownKeyType, j.KeyType)
+	}
+	for name, value := range members {
+		if value == "" {
+			return "", fmt.Errorf("%w: missing %q", ErrInvalidJWK, name)
+		}
+	}
+
+	// encoding/json sorts map keys and adds no whitespace, which is the
+	// serialization required by RFC 7638.
+	serialized, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256(serialized)
+	return base64.RawURLEncoding.EncodeToString(digest[:]), nil
+}
+
+// PublicJWK returns the public portion of the key as a JWK. The "kid" member
+// is set to the securesystemslib keyid and the "alg" member to the JWS
+// algorithm of the key's scheme, so that converting the JWK back with
+// JWK.SSLibKey results in the same keyid.
+func (k *SSLibKey) PublicJWK() (*JWK, error) {
+	public, err := k.publicCryptoKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return k.newJWK(public, nil)
+}
+
+// PrivateJWK returns the key, including its private portion, as a JWK. See
+// PublicJWK for how the "kid" and "alg" members are set.
+func (k *SSLibKey) PrivateJWK() (*JWK, error) {
+	private, err := k.privateCryptoKey()
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnknownKeyType
+	}
+
+	return k.newJWK(signer.Public(), private)
+}
+
+func (k *SSLibKey) newJWK(public crypto.PublicKey, private crypto.PrivateKey) (*JWK, error) {
+	var algorithm string
+	for alg, scheme := range jwkAlgorithms {
+		if scheme == k.Scheme {
+			algorithm = alg
+			break
+		}
+	}
+	if algorithm == "" {
+		return nil, fmt.Errorf("%w: %q has no JWS algorithm", ErrUnknownScheme, k.Scheme)
+	}
+
+	jwk := &JWK{
+		KeyID:     k.KeyID,
+		Use:       "sig",
+		Algorithm: algorithm,
+	}
+
+	switch p := public.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = JWKKeyTypeOKP
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(p)
+
+	case *ecdsa.PublicKey:
+		point, err := p.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		// Uncompressed points are 0x04 || X || Y, with X and Y padded to
+		// the size of the curve.
+		size := (len(point) - 1) / 2
+		jwk.KeyType = JWKKeyTypeEC
+		jwk.Curve = p.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(point[1 : 1+size])
+		jwk.Y = base64.RawURLEncoding.EncodeToString(point[1+size:])
+
+	case *rsa.PublicKey:
+		jwk.KeyType = JWKKeyTypeRSA
+		jwk.N = base64.RawURLEncoding.EncodeToString(p.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.E)).Bytes())
+
+	default:
+		return nil, ErrUnknownKeyType
+	}
+
+	switch p := private.(type) {
+	case nil:
+	case ed25519.PrivateKey:
+		jwk.D = base64.RawURLEncoding.EncodeToString(p.Seed())
+
+	case *ecdsa.PrivateKey:
+		d, err := p.Bytes()
+		if err != nil {
+			return nil, err
+		}
+		jwk.D = base64.RawURLEncoding.EncodeToString(d)
+
+	case *rsa.PrivateKey:
+		if len(p.Primes) != 2 {
+			return nil, fmt.Errorf("%w: multi-prime RSA keys are not supported", ErrUnknownKeyType)
+		}
+		p.Precompute()
+		jwk.D = base64.RawURLEncoding.EncodeToString(p.D.Bytes())
+		jwk.P = base64.RawURLEncoding.EncodeToString(p.Primes[0].Bytes())
+		jwk.Q = base64.RawURLEncoding.EncodeToString(p.Primes[1].Bytes())
+		jwk.DP = base64.RawURLEncoding.EncodeToString(p.Precomputed.Dp.Bytes())
+		jwk.DQ = base64.RawURLEncoding.EncodeToString(p.Precomputed.Dq.Bytes())
+		jwk.QI = base64.RawURLEncoding.EncodeToString(p.Precomputed.Qinv.Bytes())
+
+	default:
+		return nil, ErrUnknownKeyType
+	}
+
+	return jwk, nil
+}
+
+// cryptoKeys decodes the key material of the JWK. The private key is nil if
+// the JWK only holds a public key.
+func (j *JWK) cryptoKeys() (crypto.PublicKey, crypto.PrivateKey, error) {
+	switch j.KeyType {
+	case JWKKeyTypeOKP:
+		if j.Curve != "Ed25519" {
+			return nil, nil, fmt.Errorf("%w: OKP curve %q", ErrUnknownKeyType, j.Curve)
+		}
+		x, err := decodeJWKMember("x", j.X)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, nil, fmt.Errorf("%w: invalid ED25519 public key size", ErrInvalidJWK)
+		}
+		public := ed25519.PublicKey(x)
+		if j.D == "" {
+			return public, nil, nil
+		}
+
+		d, err := decodeJWKMember("d", j.D)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(d) != ed25519.SeedSize {
+			return nil, nil, fmt.Errorf("%w: invalid ED25519 private key size", ErrInvalidJWK)
+		}
+		private := ed25519.NewKeyFromSeed(d)
+		if !public.Equal(private.Public()) {
+			return nil, nil, fmt.Errorf("%w: private key does not match public key", ErrInvalidJWK)
+		}
+		return public, private, nil
+
+	case JWKKeyTypeEC:
+		curve, ok := jwkCurves[j.Curve]
+		if !ok {
+			return nil, nil, fmt.Errorf("%w: EC curve %q", ErrUnknownKeyType, j.Curve)
+		}
+		x, err := decodeJWKMember("x", j.X)
+		if err != nil {
+			return nil, nil, err
+		}
+		y, err := decodeJWKMember("y", j.Y)
+		if err != nil {
+			return nil, nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, nil, fmt.Errorf("%w: invalid EC coordinate size", ErrInvalidJWK)
+		}
+		public, err := ecdsa.ParseUncompressedPublicKey(curve, append(append([]byte{4}, x...), y...))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidJWK, err)
+		}
+		if j.D == "" {
+			return public, nil, nil
+		}
+
+		d, err := decodeJWKMember("d", j.D)
+		if err != nil {
+			return nil, nil, err
+		}
+		private, err := ecdsa.ParseRawPrivateKey(curve, d)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidJWK, err)
+		}
+		if !public.Equal(private.Public()) {
+			return nil, nil, fmt.Errorf("%w: private key does not match public key", ErrInvalidJWK)
+		}
+		return public, private, nil
+
+	case JWKKeyTypeRSA:
+		n, err := decodeJWKBigInt("n", j.N)
+		if err != nil {
+			return nil, nil, err
+		}
+		e, err := decodeJWKBigInt("e", j.E)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, nil, fmt.Errorf("%w: invalid RSA exponent", ErrInvalidJWK)
+		}
+		public := &rsa.PublicKey{N: n, E: int(e.Int64())}
+		if j.D == "" {
+			return public, nil, nil
+		}
+
+		d, err := decodeJWKBigInt("d", j.D)
+		if err != nil {
+			return nil, nil, err
+		}
+		p, err := decodeJWKBigInt("p", j.P)
+		if err != nil {
+			return nil, nil, err
+		}
+		q, err := decodeJWKBigInt("q", j.Q)
+		if err != nil {
+			return nil, nil, err
+		}
+		private := &rsa.PrivateKey{
+			PublicKey: *public,
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := private.Validate(); err != nil {
+			return nil, nil, fmt.Errorf("%w: %w", ErrInvalidJWK, err)
+		}
+		private.Precompute()
+		return public, private, nil
+
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnknownKeyType, j.KeyType)
+	}
+}
+
+func decodeJWKMember(name, value string) ([]byte, error) {
+	if value == "" {
+		return nil, fmt.Errorf("%w: missing %q", ErrInvalidJWK, name)
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q is not base64url encoded", ErrInvalidJWK, name)
+	}
+	return decoded, nil
+}
+
+func decodeJWKBigInt(name, value string) (*big.Int, error) {
+	decoded, err := decodeJWKMember(name, value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(decoded), nil
+}
@@ -0,0 +1,193 @@
+package signerverifier
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed test-data/jwks.json
+var jwkSet []byte
+
+func TestJWKRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		keyBytes        []byte
+		scheme          string
+		expectedKeyType string
+		expectedAlg     string
+	}{
+		"ED25519 key": {
+			keyBytes:        ed25519PrivateKey,
+			expectedKeyType: JWKKeyTypeOKP,
+			expectedAlg:     "EdDSA",
+		},
+		"ECDSA P-256 key": {
+			keyBytes:        ecdsaPrivateKey,
+			expectedKeyType: JWKKeyTypeEC,
+			expectedAlg:     "ES256",
+		},
+		"ECDSA P-384 key": {
+			keyBytes:        ecdsaP384PrivateKey,
+			expectedKeyType: JWKKeyTypeEC,
+			expectedAlg:     "ES384",
+		},
+		"ECDSA P-521 key": {
+			keyBytes:        ecdsaP521PrivateKey,
+			expectedKeyType: JWKKeyTypeEC,
+			expectedAlg:     "ES512",
+		},
+		"RSA-PSS key": {
+			keyBytes:        rsaPrivateKey,
+			expectedKeyType: JWKKeyTypeRSA,
+			expectedAlg:     "PS256",
+		},
+		"RSA PKCS#1 v1.5 key": {
+			keyBytes:        rsaPrivateKey,
+			scheme:          RSAPKCS1v15SHA512KeyScheme,
+			expectedKeyType: JWKKeyTypeRSA,
+			expectedAlg:     "RS512",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, err := LoadKey(test.keyBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.scheme != "" {
+				key.Scheme = test.scheme
+				key.KeyID, err = calculateKeyID(key)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			publicJWK, err := key.PublicJWK()
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedKeyType, publicJWK.KeyType)
+			assert.Equal(t, test.expectedAlg, publicJWK.Algorithm)
+			assert.Equal(t, key.KeyID, publicJWK.KeyID)
+			assert.Empty(t, publicJWK.D)
+
+			publicKey, err := publicJWK.SSLibKey()
+			assert.Nil(t, err)
+			assert.Equal(t, key.KeyID, publicKey.KeyID)
+			assert.Equal(t, key.Scheme, publicKey.Scheme)
+			assert.Equal(t, key.KeyVal.Public, publicKey.KeyVal.Public)
+			assert.Empty(t, publicKey.KeyVal.Private)
+
+			privateJWK, err := key.PrivateJWK()
+			assert.Nil(t, err)
+			assert.NotEmpty(t, privateJWK.D)
+
+			// Round trip through the JSON encoding.
+			privateJWKBytes, err := json.Marshal(privateJWK)
+			assert.Nil(t, err)
+			privateKey, err := LoadKeyFromJWK(privateJWKBytes)
+			assert.Nil(t, err)
+			assert.Equal(t, key.KeyID, privateKey.KeyID)
+
+			sv, err := NewSignerVerifierFromSSLibKey(privateKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verifier, err := NewSignerVerifierFromSSLibKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			signature, err := sv.Sign(context.Background(), []byte("test message"))
+			assert.Nil(t, err)
+			assert.Nil(t, verifier.Verify(context.Background(), []byte("test message"), signature))
+
+			publicThumbprint, err := publicJWK.Thumbprint()
+			assert.Nil(t, err)
+			privateThumbprint, err := privateJWK.Thumbprint()
+			assert.Nil(t, err)
+			assert.Equal(t, publicThumbprint, privateThumbprint)
+		})
+	}
+
+	t.Run("public key has no private JWK", func(t *testing.T) {
+		key, err := LoadKey(ecdsaPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = key.PrivateJWK()
+		assert.ErrorIs(t, err, ErrNotPrivateKey)
+	})
+}
+
+func TestLoadKeyFromJWK(t *testing.T) {
+	t.Run("RFC 8037 ED25519 private key", func(t *testing.T) {
+		key, err := LoadKeyFromJWK([]byte(`{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`))
+		assert.Nil(t, err)
+		assert.Equal(t, ED25519KeyType, key.KeyType)
+		assert.Equal(t, ED25519KeyType, key.Scheme)
+		assert.Equal(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a", key.KeyVal.Public)
+		assert.Equal(t, "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a", key.KeyVal.Private)
+	})
+
+	t.Run("algorithm does not match key", func(t *testing.T) {
+		_, err := LoadKeyFromJWK([]byte(`{"kty":"OKP","crv":"Ed25519","alg":"ES256","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`))
+		assert.ErrorIs(t, err, ErrSchemeMismatch)
+	})
+
+	t.Run("unknown algorithm", func(t *testing.T) {
+		_, err := LoadKeyFromJWK([]byte(`{"kty":"OKP","crv":"Ed25519","alg":"HS256","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`))
+		assert.ErrorIs(t, err, ErrUnknownScheme)
+	})
+
+	t.Run("private key does not match public key", func(t *testing.T) {
+		_, err := LoadKeyFromJWK([]byte(`{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"3Zx0X9cUm8Uwlt8_6d2o5KbXb5K3O_oqX1lLhZpZbRg"}`))
+		assert.ErrorIs(t, err, ErrInvalidJWK)
+	})
+
+	t.Run("missing member", func(t *testing.T) {
+		_, err := LoadKeyFromJWK([]byte(`{"kty":"EC","crv":"P-256","x":"f83OJ3D2xF1Bg8vub9tLe1gHMzV76e8Tus9uPHvRVEU"}`))
+		assert.ErrorIs(t, err, ErrInvalidJWK)
+	})
+
+	t.Run("unsupported key type", func(t *testing.T) {
+		_, err := LoadKeyFromJWK([]byte(`{"kty":"oct","k":"AyM1SysPpbyDfgZld3umj1qzKObwVMkoqQ-EstJQLr_T-1qS0gZH75aKtMN3Yj0iPS4hcgUuTwjAzZr1Z9CAow"}`))
+		assert.ErrorIs(t, err, ErrUnknownKeyType)
+	})
+}
+
+func TestLoadKeysFromJWKSet(t *testing.T) {
+	keys, err := LoadKeysFromJWKSet(jwkSet)
+	assert.Nil(t, err)
+
+	// The X25519, encryption, and symmetric keys are ignored.
+	assert.Len(t, keys, 2)
+	assert.Equal(t, RSAKeyType, keys[0].KeyType)
+	assert.Equal(t, RSAPKCS1v15SHA256KeyScheme, keys[0].Scheme)
+	assert.Equal(t, ED25519KeyType, keys[1].KeyType)
+
+	_, err = LoadKeysFromJWKSet([]byte(`{}`))
+	assert.ErrorIs(t, err, ErrInvalidJWK)
+}
+
+func TestJWKThumbprint(t *testing.T) {
+	set := &JWKSet{}
+	if err := json.Unmarshal(jwkSet, set); err != nil {
+		t.Fatal(err)
+	}
+
+	// RFC 7638, section 3.1
+	thumbprint, err := set.Keys[0].Thumbprint()
+	assert.Nil(t, err)
+	assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+
+	// RFC 8037, appendix A.3
+	thumbprint, err = set.Keys[1].Thumbprint()
+	assert.Nil(t, err)
+	assert.Equal(t, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", thumbprint)
+
+	_, err = set.Keys[4].Thumbprint()
+	assert.ErrorIs(t, err, ErrUnknownKeyType)
+}
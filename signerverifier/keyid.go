@@ -0,0 +1,140 @@
+package signerverifier
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/secure-systems-lab/go-securesystemslib/cjson"
+)
+
+const (
+	KeyIDHashAlgorithmSHA256 = "sha256"
+	KeyIDHashAlgorithmSHA512 = "sha512"
+)
+
+var (
+	ErrUnknownKeyIDHashAlgorithm = errors.New("unknown keyid hash algorithm")
+	ErrKeyIDMismatch             = errors.New("keyid does not match key")
+)
+
+var keyIDHashAlgorithms = map[string]func() hash.Hash{
+	KeyIDHashAlgorithmSHA256: sha256.New,
+	KeyIDHashAlgorithmSHA512: sha512.New,
+}
+
+// CalculateKeyID returns the keyid of the key computed with the given hash
+// algorithm, "sha256" or "sha512". The keyid is the hex encoded digest of the
+// canonical JSON encoding of the key's public portion, as computed by
+// python-securesystemslib: "keytype", "scheme", the public fields of
+// "keyval", and "keyid_hash_algorithms" only if the key lists any.
+func (k *SSLibKey) CalculateKeyID(hashAlgorithm string) (string, error) {
+	newHash, ok := keyIDHashAlgorithms[hashAlgorithm]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownKeyIDHashAlgorithm, hashAlgorithm)
+	}
+
+	canonical, err := cjson.EncodeCanonical(k.publicMetadata())
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hashBeforeSigning(canonical, newHash())), nil
+}
+
+// publicKeyVal returns the fields of the key's keyval that are set, except
+// the private portion.
+func (k *SSLibKey) publicKeyVal() map[string]string {
+	keyVal := map[string]string{}
+	for name, value := range map[string]string{
+		"public":      k.KeyVal.Public,
+		"certificate": k.KeyVal.Certificate,
+		"identity":    k.KeyVal.Identity,
+		"issuer":      k.KeyVal.Issuer,
+	} {
+		if value != "" {
+			keyVal[name] = value
+		}
+	}
+	return keyVal
+}
+
+// publicMetadata returns the public portion of the key in the shape
+// python-securesystemslib and TUF use for keys in metadata, from which both
+// the keyid and MarshalCanonical are computed. keyid_hash_algorithms is
+// omitted if the key lists none, as for keys created by current
+// python-securesystemslib.
+func (k *SSLibKey) publicMetadata() map[string]any {
+	key := map[string]any{
+		"keytype": k.KeyType,
+		"scheme":  k.Scheme,
+		"keyval":  k.publicKeyVal(),
+	}
+	if len(k.KeyIDHashAlgorithms) > 0 {
+		key["keyid_hash_algorithms"] = k.KeyIDHashAlgorithms
+	}
+	return key
+}
+
+// KeyIDs returns the keyid of the key for each algorithm listed in its
+// keyid_hash_algorithms, indexed by algorithm. Keys that list no algorithms
+// are identified by their SHA-256 keyid. Unknown algorithms, which other
+// implementations may list, are skipped; ErrUnknownKeyIDHashAlgorithm is
+// returned only if none of the listed algorithms is known.
+func (k *SSLibKey) KeyIDs() (map[string]string, error) {
+	hashAlgorithms := k.KeyIDHashAlgorithms
+	if len(hashAlgorithms) == 0 {
+		hashAlgorithms = []string{KeyIDHashAlgorithmSHA256}
+	}
+
+	keyIDs := make(map[string]string, len(hashAlgorithms))
+	for _, hashAlgorithm := range hashAlgorithms {
+		if _, ok := keyIDHashAlgorithms[hashAlgorithm]; !ok {
+			continue
+		}
+		keyID, err := k.CalculateKeyID(hashAlgorithm)
+		if err != nil {
+			return nil, err
+		}
+		keyIDs[hashAlgorithm] = keyID
+	}
+	if len(keyIDs) == 0 {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKeyIDHashAlgorithm, hashAlgorithms)
+	}
+
+	return keyIDs, nil
+}
+
+// MatchesKeyID reports whether keyID identifies the key using any of the
+// algorithms listed in its keyid_hash_algorithms. This allows matching keys
+// referenced by SHA-512 keyids in legacy TUF and in-toto metadata.
+func (k *SSLibKey) MatchesKeyID(keyID string) (bool, error) {
+	keyIDs, err := k.KeyIDs()
+	if err != nil {
+		return false, err
+	}
+
+	for _, candidate := range keyIDs {
+		if candidate == keyID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// VerifyKeyID checks that the key's keyid was computed from the key using one
+// of the algorithms listed in its keyid_hash_algorithms. ErrKeyIDMismatch is
+// returned if the keyid or the key have been tampered with.
+func (k *SSLibKey) VerifyKeyID() error {
+	matches, err := k.MatchesKeyID(k.KeyID)
+	if err != nil {
+		return err
+	}
+	if !matches {
+		return fmt.Errorf("%w: %q", ErrKeyIDMismatch, k.KeyID)
+	}
+	return nil
+}
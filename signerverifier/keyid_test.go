@@ -0,0 +1,185 @@
+package signerverifier
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyIDs(t *testing.T) {
+	// Computed with python-securesystemslib's canonical JSON encoding
+	expectedSHA256KeyID := "52e3b8e73279d6ebdd62a5016e2725ff284f569665eb92ccb145d83817a02997"
+	expectedSHA512KeyID := "d8f8da5c7b3a9a2415018925e8a51eb18a0b17cbac83dc9cf3d827726e0803b5b3b20ab62c1cfa1d93912a172d44d43a70f3c92cbabe8ccd3329ce6acb9dbba6"
+
+	key, err := LoadKey(ed25519PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyID, err := key.CalculateKeyID(KeyIDHashAlgorithmSHA512)
+	assert.Nil(t, err)
+	assert.Equal(t, expectedSHA512KeyID, keyID)
+
+	keyIDs, err := key.KeyIDs()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{
+		KeyIDHashAlgorithmSHA256: expectedSHA256KeyID,
+		KeyIDHashAlgorithmSHA512: expectedSHA512KeyID,
+	}, keyIDs)
+
+	for _, keyID := range []string{expectedSHA256KeyID, expectedSHA512KeyID} {
+		matches, err := key.MatchesKeyID(keyID)
+		assert.Nil(t, err)
+		assert.True(t, matches)
+	}
+
+	matches, err := key.MatchesKeyID("98adf38602c48c5479e9a991ee3f8cbf541ee4f985e00f7a5fc4148d9a45b704")
+	assert.Nil(t, err)
+	assert.False(t, matches)
+
+	t.Run("only SHA-256 advertised", func(t *testing.T) {
+		key := *key
+		key.KeyIDHashAlgorithms = []string{KeyIDHashAlgorithmSHA256}
+
+		keyIDs, err := key.KeyIDs()
+		assert.Nil(t, err)
+		assert.Len(t, keyIDs, 1)
+
+		// The advertised algorithms are part of the canonical key, so the
+		// keyid changes as well.
+		assert.NotEqual(t, expectedSHA256KeyID, keyIDs[KeyIDHashAlgorithmSHA256])
+	})
+
+	t.Run("no algorithms advertised", func(t *testing.T) {
+		key := *key
+		key.KeyIDHashAlgorithms = nil
+
+		// keyid_hash_algorithms is left out of the canonical key, as by
+		// current python-securesystemslib.
+		keyIDs, err := key.KeyIDs()
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]string{
+			KeyIDHashAlgorithmSHA256: "2724d74f8fa3956502310945eae08cefaf73cace47f5912e55c16eb5f86ba067",
+		}, keyIDs)
+	})
+
+	t.Run("whole keyval", func(t *testing.T) {
+		key := &SSLibKey{
+			KeyType: SigstoreKeyType,
+			Scheme:  SigstoreKeyScheme,
+			KeyVal: KeyVal{
+				Identity: "jane@example.com",
+				Issuer:   "https://accounts.example.com",
+			},
+		}
+		keyID, err := key.CalculateKeyID(KeyIDHashAlgorithmSHA256)
+		assert.Nil(t, err)
+
+		other := *key
+		other.KeyVal.Identity = "john@example.com"
+		otherKeyID, err := other.CalculateKeyID(KeyIDHashAlgorithmSHA256)
+		assert.Nil(t, err)
+		assert.NotEqual(t, keyID, otherKeyID)
+
+		// The private portion is not part of the keyid.
+		other = *key
+		other.KeyVal.Private = "secret"
+		otherKeyID, err = other.CalculateKeyID(KeyIDHashAlgorithmSHA256)
+		assert.Nil(t, err)
+		assert.Equal(t, keyID, otherKeyID)
+	})
+
+	t.Run("unknown algorithm", func(t *testing.T) {
+		_, err := key.CalculateKeyID("md5")
+		assert.ErrorIs(t, err, ErrUnknownKeyIDHashAlgorithm)
+
+		key := *key
+		key.KeyIDHashAlgorithms = []string{"md5"}
+		_, err = key.KeyIDs()
+		assert.ErrorIs(t, err, ErrUnknownKeyIDHashAlgorithm)
+
+		// Unknown algorithms listed alongside known ones are skipped.
+		key.KeyIDHashAlgorithms = []string{"sha256", "md5"}
+		keyIDs, err := key.KeyIDs()
+		assert.Nil(t, err)
+		assert.Len(t, keyIDs, 1)
+		assert.Contains(t, keyIDs, KeyIDHashAlgorithmSHA256)
+	})
+}
+
+func TestVerifyKeyID(t *testing.T) {
+	key, err := LoadKey(ecdsaPublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, key.VerifyKeyID())
+
+	key.KeyID, err = key.CalculateKeyID(KeyIDHashAlgorithmSHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, key.VerifyKeyID())
+
+	key.Scheme = ECDSAP384KeyScheme
+	assert.ErrorIs(t, key.VerifyKeyID(), ErrKeyIDMismatch)
+}
+
+func TestLoadSSLibKeyStrictWithKeyID(t *testing.T) {
+	contents, err := os.ReadFile(filepath.Join("test-data", "ed25519-test-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("SHA-512 keyid", func(t *testing.T) {
+		legacy := strings.Replace(string(contents), "52e3b8e73279d6ebdd62a5016e2725ff284f569665eb92ccb145d83817a02997", "d8f8da5c7b3a9a2415018925e8a51eb18a0b17cbac83dc9cf3d827726e0803b5b3b20ab62c1cfa1d93912a172d44d43a70f3c92cbabe8ccd3329ce6acb9dbba6", 1)
+
+		key, err := LoadSSLibKeyStrict([]byte(legacy))
+		assert.Nil(t, err)
+		assert.Equal(t, "d8f8da5c7b3a9a2415018925e8a51eb18a0b17cbac83dc9cf3d827726e0803b5b3b20ab62c1cfa1d93912a172d44d43a70f3c92cbabe8ccd3329ce6acb9dbba6", key.KeyID)
+	})
+
+	t.Run("unknown keyid hash algorithm", func(t *testing.T) {
+		key, err := LoadKeyFromSSLibBytes(contents)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.KeyIDHashAlgorithms = append(key.KeyIDHashAlgorithms, "blake2b")
+		key.KeyID, err = key.CalculateKeyID(KeyIDHashAlgorithmSHA256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyBytes, err := json.Marshal(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := LoadSSLibKeyStrict(keyBytes)
+		assert.Nil(t, err)
+		assert.Equal(t, key.KeyID, loaded.KeyID)
+	})
+
+	t.Run("tampered keyid", func(t *testing.T) {
+		tampered := strings.Replace(string(contents), "52e3b8e7", "00000000", 1)
+
+		_, err := LoadSSLibKeyStrict([]byte(tampered))
+		assert.ErrorIs(t, err, ErrKeyIDMismatch)
+
+		// The deprecated loader does not check keyids.
+		key, err := LoadKeyFromSSLibBytes([]byte(tampered))
+		assert.Nil(t, err)
+		assert.Equal(t, "000000003279d6ebdd62a5016e2725ff284f569665eb92ccb145d83817a02997", key.KeyID)
+	})
+
+	t.Run("tampered public key", func(t *testing.T) {
+		// The public key is replaced by another valid one, so only the keyid
+		// reveals the change.
+		tampered := strings.Replace(string(contents), `"public": "3f586ce67329419fb0081bd995914e866a7205da463d593b3b490eab2b27fd3f", "private": "66f6ebad4aeb949b91c84c9cfd6ee351fc4fd544744bab6e30fb400ba13c6e9a"`, `"public": "7103d7639fd2892a35aaec1df32404f4a8c95eb7eac6d2cd127f5f4acc681e94"`, 1)
+
+		_, err := LoadSSLibKeyStrict([]byte(tampered))
+		assert.ErrorIs(t, err, ErrKeyIDMismatch)
+	})
+}
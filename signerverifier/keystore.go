@@ -0,0 +1,314 @@
+package signerverifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+var (
+	ErrKeyNotFound    = errors.New("key not found in keystore")
+	ErrDuplicateKeyID = errors.New("keyid is shared by different keys")
+)
+
+// KeystoreOption configures OpenKeystore.
+type KeystoreOption func(*Keystore)
+
+// WithKeystorePassphraseFunc sets the function used to obtain the passphrase
+// of encrypted key files, called with the path of the file. Without it,
+// encrypted files fail to load.
+func WithKeystorePassphraseFunc(passphraseFunc func(path string) ([]byte, error)) KeystoreOption {
+	return func(ks *Keystore) {
+		ks.passphraseFunc = passphraseFunc
+	}
+}
+
+// Keystore holds the keys found in a directory, indexed by keyid. Each file
+// holds one key in any format accepted by LoadKey or LoadSSLibKeyStrict, or
+// one encrypted with the encrypted package or as PKCS #8, see
+// LoadKeyWithPassphrase. Hidden files and subdirectories are skipped.
+//
+// Keys are indexed by every keyid they are known by: the keyid stored with the
+// key, the keyid for each of its keyid_hash_algorithms, and, for key types
+// supported by SSH, dsse.SHA256KeyID of the public key. When a private key and
+// its public key are both present, lookups return the private key. Keyids
+// stored with keys are checked with VerifyKeyID, and loading fails with
+// ErrDuplicateKeyID if different keys, or the same key with different schemes,
+// share a keyid.
+//
+// A Keystore is safe for concurrent use, including concurrent calls to Reload.
+type Keystore struct {
+	dir            string
+	passphraseFunc func(path string) ([]byte, error)
+
+	reloadMu sync.Mutex
+
+	mu     sync.RWMutex
+	files  map[string]*keystoreFile
+	keyIDs map[string]*SSLibKey
+}
+
+type keystoreFile struct {
+	modTime time.Time
+	size    int64
+	key     *SSLibKey
+}
+
+// OpenKeystore loads the keys in dir.
+func OpenKeystore(dir string, opts ...KeystoreOption) (*Keystore, error) {
+	ks := &Keystore{
+		dir:    dir,
+		files:  map[string]*keystoreFile{},
+		keyIDs: map[string]*SSLibKey{},
+	}
+	for _, opt := range opts {
+		opt(ks)
+	}
+
+	if err := ks.Reload(); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// Reload rescans the directory, loading new and modified files and dropping
+// removed ones. Files whose size and modification time are unchanged are not
+// read again. The keystore is updated atomically: if any file fails to load,
+// the keys loaded previously remain in use and the error is returned.
+func (ks *Keystore) Reload() error {
+	ks.reloadMu.Lock()
+	defer ks.reloadMu.Unlock()
+
+	entries, err := os.ReadDir(ks.dir)
+	if err != nil {
+		return fmt.Errorf("unable to load keystore: %w", err)
+	}
+
+	ks.mu.RLock()
+	previous := ks.files
+	ks.mu.RUnlock()
+
+	files := make(map[string]*keystoreFile, len(entries))
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to load key %s: %w", name, err))
+			continue
+		}
+		if file, ok := previous[name]; ok && file.modTime.Equal(info.ModTime()) && file.size == info.Size() {
+			files[name] = file
+			continue
+		}
+
+		key, err := ks.loadFile(filepath.Join(ks.dir, name))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("unable to load key %s: %w", name, err))
+			continue
+		}
+		files[name] = &keystoreFile{modTime: info.ModTime(), size: info.Size(), key: key}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	keyIDs, err := indexKeystoreFiles(files)
+	if err != nil {
+		return err
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.files = files
+	ks.keyIDs = keyIDs
+	return nil
+}
+
+// Watch calls Reload every interval until ctx is done. Errors are passed to
+// onError, which may be nil to ignore them.
+func (ks *Keystore) Watch(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ks.Reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// KeyIDs returns the keyids of all keys in the keystore, sorted, using the
+// keyid stored with each key.
+func (ks *Keystore) KeyIDs() []string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	seen := map[*SSLibKey]bool{}
+	keyIDs := []string{}
+	for _, key := range ks.keyIDs {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keyIDs = append(keyIDs, key.KeyID)
+	}
+	sort.Strings(keyIDs)
+	return keyIDs
+}
+
+// Key returns the public portion of the key identified by keyID.
+func (ks *Keystore) Key(keyID string) (*SSLibKey, error) {
+	key, err := ks.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return key.PublicOnly(), nil
+}
+
+// Signer returns a signer for the key identified by keyID. ErrNotPrivateKey is
+// returned if the keystore only holds its public portion.
+func (ks *Keystore) Signer(keyID string) (dsse.SignerVerifier, error) {
+	key, err := ks.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(key.KeyVal.Private) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrNotPrivateKey, keyID)
+	}
+	return NewSignerVerifierFromSSLibKey(key)
+}
+
+// Verifier returns a verifier for the key identified by keyID.
+func (ks *Keystore) Verifier(keyID string) (dsse.Verifier, error) {
+	key, err := ks.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return NewSignerVerifierFromSSLibKey(key.PublicOnly())
+}
+
+// Verifiers returns a verifier for each key in the keystore, in the order of
+// KeyIDs, ready to be passed to dsse.NewEnvelopeVerifier.
+func (ks *Keystore) Verifiers() ([]dsse.Verifier, error) {
+	keyIDs := ks.KeyIDs()
+	verifiers := make([]dsse.Verifier, 0, len(keyIDs))
+	for _, keyID := range keyIDs {
+		verifier, err := ks.Verifier(keyID)
+		if err != nil {
+			return nil, err
+		}
+		verifiers = append(verifiers, verifier)
+	}
+	return verifiers, nil
+}
+
+func (ks *Keystore) key(keyID string) (*SSLibKey, error) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	key, ok := ks.keyIDs[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, keyID)
+	}
+	return key, nil
+}
+
+func (ks *Keystore) loadFile(path string) (*SSLibKey, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var passphraseFunc func() ([]byte, error)
+	if ks.passphraseFunc != nil {
+		passphraseFunc = func() ([]byte, error) {
+			return ks.passphraseFunc(path)
+		}
+	}
+
+	key, err := loadKeyBytes(contents, passphraseFunc)
+	if err != nil {
+		return nil, err
+	}
+	if key.KeyID == "" {
+		key.KeyID, err = calculateKeyID(key)
+		if err != nil {
+			return nil, err
+		}
+	} else if err := key.VerifyKeyID(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// indexKeystoreFiles maps every keyid of the keys in files to the key,
+// preferring keys with a private portion. ErrDuplicateKeyID is returned if a
+// keyid is shared by keys that are not the same.
+func indexKeystoreFiles(files map[string]*keystoreFile) (map[string]*SSLibKey, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	keyIDs := map[string]*SSLibKey{}
+	for _, name := range names {
+		key := files[name].key
+
+		ids, err := key.KeyIDs()
+		if err != nil {
+			return nil, fmt.Errorf("unable to load key %s: %w", name, err)
+		}
+		public, err := key.publicCryptoKey()
+		if err != nil {
+			return nil, fmt.Errorf("unable to load key %s: %w", name, err)
+		}
+
+		all := []string{key.KeyID}
+		for _, id := range ids {
+			all = append(all, id)
+		}
+		// dsse.SHA256KeyID is only defined for key types supported by SSH.
+		if sshKeyID, err := dsse.SHA256KeyID(public); err == nil {
+			all = append(all, sshKeyID)
+		}
+		for _, id := range all {
+			existing, ok := keyIDs[id]
+			if ok && !sameKey(existing, key) {
+				return nil, fmt.Errorf("unable to load key %s: %w: %s", name, ErrDuplicateKeyID, id)
+			}
+			if ok && existing.KeyVal.Private != "" {
+				continue
+			}
+			keyIDs[id] = key
+		}
+	}
+
+	return keyIDs, nil
+}
+
+// sameKey reports whether a and b hold the same public key for the same
+// scheme, as a private key and its public key do.
+func sameKey(a, b *SSLibKey) bool {
+	return a.KeyType == b.KeyType && a.Scheme == b.Scheme && maps.Equal(a.publicKeyVal(), b.publicKeyVal())
+}
@@ -0,0 +1,319 @@
+package signerverifier
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestKeystore(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, contents := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), contents, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestKeystore(t *testing.T) {
+	ed25519SSLibKey, err := os.ReadFile(filepath.Join("test-data", "ed25519-test-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := writeTestKeystore(t, map[string][]byte{
+		"ed25519":   ed25519SSLibKey,
+		"ecdsa":     ecdsaPrivateKey,
+		"ecdsa.pub": ecdsaPublicKey,
+		"rsa":       rsaEncryptedPrivateKey,
+		"p384.pub":  ecdsaP384PublicKey,
+		".hidden":   []byte("not a key"),
+	})
+
+	ks, err := OpenKeystore(dir, WithKeystorePassphraseFunc(func(path string) ([]byte, error) {
+		assert.Equal(t, filepath.Join(dir, "rsa"), path)
+		return testPassphrase, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadKey := func(t *testing.T, keyBytes []byte) *SSLibKey {
+		t.Helper()
+		key, err := LoadKey(keyBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	sslibKey, err := LoadSSLibKeyStrict(ed25519SSLibKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]*SSLibKey{
+		"ed25519": sslibKey,
+		"ecdsa":   loadKey(t, ecdsaPublicKey),
+		"rsa":     loadKey(t, rsaPrivateKey),
+	}
+
+	assert.ElementsMatch(t, []string{keys["ed25519"].KeyID, keys["ecdsa"].KeyID, keys["rsa"].KeyID, loadKey(t, ecdsaP384PublicKey).KeyID}, ks.KeyIDs())
+
+	message := []byte("test message")
+
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			keyIDs, err := key.KeyIDs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			public, err := key.publicCryptoKey()
+			if err != nil {
+				t.Fatal(err)
+			}
+			sshKeyID, err := dsse.SHA256KeyID(public)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lookups := []string{key.KeyID, sshKeyID}
+			for _, keyID := range keyIDs {
+				lookups = append(lookups, keyID)
+			}
+			for _, keyID := range lookups {
+				stored, err := ks.Key(keyID)
+				if assert.Nil(t, err, keyID) {
+					assert.Equal(t, key.KeyID, stored.KeyID)
+					assert.Empty(t, stored.KeyVal.Private)
+				}
+			}
+
+			signer, err := ks.Signer(sshKeyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			es, err := dsse.NewEnvelopeSigner(signer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env, err := es.SignPayload(context.Background(), "application/vnd.dsse+json", message)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			verifier, err := ks.Verifier(key.KeyID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev, err := dsse.NewEnvelopeVerifier(verifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = ev.Verify(context.Background(), env)
+			assert.Nil(t, err)
+
+			verifiers, err := ks.Verifiers()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev, err = dsse.NewEnvelopeVerifier(verifiers...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			acceptedKeys, err := ev.Verify(context.Background(), env)
+			assert.Nil(t, err)
+			assert.Len(t, acceptedKeys, 1)
+		})
+	}
+
+	t.Run("public key only", func(t *testing.T) {
+		keyID := loadKey(t, ecdsaP384PublicKey).KeyID
+
+		_, err := ks.Signer(keyID)
+		assert.ErrorIs(t, err, ErrNotPrivateKey)
+
+		_, err = ks.Verifier(keyID)
+		assert.Nil(t, err)
+	})
+
+	t.Run("unknown keyid", func(t *testing.T) {
+		_, err := ks.Key("unknown")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		_, err = ks.Signer("unknown")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+
+		_, err = ks.Verifier("unknown")
+		assert.ErrorIs(t, err, ErrKeyNotFound)
+	})
+}
+
+func TestKeystoreErrors(t *testing.T) {
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := OpenKeystore(filepath.Join(t.TempDir(), "missing"))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("encrypted key without passphrase", func(t *testing.T) {
+		dir := writeTestKeystore(t, map[string][]byte{"rsa": rsaEncryptedPrivateKey})
+		_, err := OpenKeystore(dir)
+		assert.ErrorIs(t, err, ErrPassphraseRequired)
+	})
+
+	t.Run("encrypted key with wrong passphrase", func(t *testing.T) {
+		dir := writeTestKeystore(t, map[string][]byte{"rsa": rsaEncryptedPrivateKey})
+		_, err := OpenKeystore(dir, WithKeystorePassphraseFunc(func(string) ([]byte, error) {
+			return []byte("wrong passphrase"), nil
+		}))
+		assert.ErrorIs(t, err, ErrIncorrectPassphrase)
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		dir := writeTestKeystore(t, map[string][]byte{"invalid": []byte("not a key")})
+		_, err := OpenKeystore(dir)
+		assert.ErrorContains(t, err, "invalid")
+	})
+
+	t.Run("tampered keyid", func(t *testing.T) {
+		contents, err := os.ReadFile(filepath.Join("test-data", "ed25519-test-key"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tampered := strings.Replace(string(contents), "52e3b8e7", "00000000", 1)
+
+		dir := writeTestKeystore(t, map[string][]byte{"ed25519": []byte(tampered)})
+		_, err = OpenKeystore(dir)
+		assert.ErrorIs(t, err, ErrKeyIDMismatch)
+	})
+
+	t.Run("keyid shared by different keys", func(t *testing.T) {
+		// The same RSA key with another scheme has the same SSH keyid.
+		key, err := LoadKey(rsaPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.Scheme = RSAPKCS1v15SHA256KeyScheme
+		key.KeyID, err = calculateKeyID(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyBytes, err := json.Marshal(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		dir := writeTestKeystore(t, map[string][]byte{
+			"rsa-pss":   rsaPublicKey,
+			"rsa-pkcs1": keyBytes,
+		})
+		_, err = OpenKeystore(dir)
+		assert.ErrorIs(t, err, ErrDuplicateKeyID)
+	})
+}
+
+func TestKeystoreReload(t *testing.T) {
+	dir := writeTestKeystore(t, map[string][]byte{"ecdsa": ecdsaPrivateKey})
+
+	ks, err := OpenKeystore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecdsaKey, err := LoadKey(ecdsaPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ed25519Key, err := LoadKey(ed25519PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{ecdsaKey.KeyID}, ks.KeyIDs())
+
+	// Added files are loaded.
+	if err := os.WriteFile(filepath.Join(dir, "ed25519"), ed25519PrivateKey, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, ks.Reload())
+	assert.ElementsMatch(t, []string{ecdsaKey.KeyID, ed25519Key.KeyID}, ks.KeyIDs())
+
+	// Invalid files leave the keystore unchanged.
+	if err := os.WriteFile(filepath.Join(dir, "invalid"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotNil(t, ks.Reload())
+	assert.ElementsMatch(t, []string{ecdsaKey.KeyID, ed25519Key.KeyID}, ks.KeyIDs())
+	if err := os.Remove(filepath.Join(dir, "invalid")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Modified files are loaded again.
+	if err := os.WriteFile(filepath.Join(dir, "ecdsa"), ecdsaP384PublicKey, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "ecdsa"), modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	p384Key, err := LoadKey(ecdsaP384PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, ks.Reload())
+	assert.ElementsMatch(t, []string{p384Key.KeyID, ed25519Key.KeyID}, ks.KeyIDs())
+	_, err = ks.Key(ecdsaKey.KeyID)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	// Removed files are dropped.
+	if err := os.Remove(filepath.Join(dir, "ecdsa")); err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, ks.Reload())
+	assert.Equal(t, []string{ed25519Key.KeyID}, ks.KeyIDs())
+
+	t.Run("concurrent use", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				assert.Nil(t, ks.Reload())
+			}()
+			go func() {
+				defer wg.Done()
+				signer, err := ks.Signer(ed25519Key.KeyID)
+				if assert.Nil(t, err) {
+					_, err = signer.Sign(context.Background(), []byte("test message"))
+					assert.Nil(t, err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go ks.Watch(ctx, 10*time.Millisecond, nil)
+
+		if err := os.WriteFile(filepath.Join(dir, "ecdsa"), ecdsaPrivateKey, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		assert.Eventually(t, func() bool {
+			_, err := ks.Key(ecdsaKey.KeyID)
+			return err == nil
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
@@ -0,0 +1,211 @@
+//go:build go1.27
+
+package signerverifier
+
+import (
+	"context"
+	"crypto"
+	"crypto/mldsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+// ML-DSA keys use the same name for their keytype and scheme, as the
+// parameter set fixes the signing algorithm.
+const (
+	MLDSA65KeyType = "ml-dsa-65"
+	MLDSA87KeyType = "ml-dsa-87"
+)
+
+var mldsaParameters = map[string]mldsa.Parameters{
+	MLDSA65KeyType: mldsa.MLDSA65(),
+	MLDSA87KeyType: mldsa.MLDSA87(),
+}
+
+func init() {
+	mldsaFactory := func(key *SSLibKey) (dsse.SignerVerifier, error) {
+		return NewMLDSASignerVerifierFromSSLibKey(key)
+	}
+	for keyType := range mldsaParameters {
+		RegisterSignerVerifierFactory(keyType, keyType, mldsaFactory)
+	}
+}
+
+// MLDSASignerVerifier is a dsse.SignerVerifier compliant interface to sign and
+// verify signatures using the post-quantum ML-DSA scheme specified in FIPS
+// 204. Keys are serialized like ECDSA and RSA keys, as PEM encoded PKIX public
+// keys and seed-only PKCS8 private keys.
+type MLDSASignerVerifier struct {
+	keyID   string
+	private *mldsa.PrivateKey
+	public  *mldsa.PublicKey
+}
+
+// NewMLDSASignerVerifierFromSSLibKey creates an MLDSASignerVerifier from an
+// SSLibKey. The key's scheme must match its keytype and parameter set.
+func NewMLDSASignerVerifierFromSSLibKey(key *SSLibKey) (*MLDSASignerVerifier, error) {
+	if len(key.KeyVal.Public) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	params, ok := mldsaParameters[key.KeyType]
+	if !ok {
+		return nil, fmt.Errorf("unable to create ML-DSA signerverifier: %w: %q", ErrUnknownKeyType, key.KeyType)
+	}
+	if key.Scheme != key.KeyType {
+		return nil, fmt.Errorf("unable to create ML-DSA signerverifier: %w: %q for keytype %q", ErrUnknownScheme, key.Scheme, key.KeyType)
+	}
+
+	_, publicParsedKey, err := decodeAndParsePEM([]byte(key.KeyVal.Public))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create ML-DSA signerverifier: %w", err)
+	}
+
+	public, ok := publicParsedKey.(*mldsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unable to create ML-DSA signerverifier: %w", ErrUnknownKeyType)
+	}
+	if public.Parameters() != params {
+		return nil, fmt.Errorf("unable to create ML-DSA signerverifier: %w: scheme %q used with %s key", ErrSchemeMismatch, key.Scheme, public.Parameters())
+	}
+
+	sv := &MLDSASignerVerifier{
+		keyID:  key.KeyID,
+		public: public,
+	}
+
+	if len(key.KeyVal.Private) > 0 {
+		_, privateParsedKey, err := decodeAndParsePEM([]byte(key.KeyVal.Private))
+		if err != nil {
+			return nil, fmt.Errorf("unable to create ML-DSA signerverifier: %w", err)
+		}
+
+		private, ok := privateParsedKey.(*mldsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unable to create ML-DSA signerverifier: %w", ErrUnknownKeyType)
+		}
+		sv.private = private
+	}
+
+	return sv, nil
+}
+
+// GenerateMLDSAKey creates a new ML-DSA key of the given keytype,
+// MLDSA65KeyType or MLDSA87KeyType, and returns it as an SSLibKey with both
+// the public and private portions populated.
+func GenerateMLDSAKey(keyType string) (*SSLibKey, error) {
+	params, ok := mldsaParameters[keyType]
+	if !ok {
+		return nil, fmt.Errorf("unable to generate ML-DSA key: %w: %q", ErrUnknownKeyType, keyType)
+	}
+
+	private, err := mldsa.GenerateKey(params)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate ML-DSA key: %w", err)
+	}
+
+	return newSSLibKeyFromPrivateKey(private)
+}
+
+// Sign creates a signature for `data`. Signatures are hedged: they mix fresh
+// randomness with the private key, as recommended by FIPS 204.
+func (sv *MLDSASignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
+	if sv.private == nil {
+		return nil, ErrNotPrivateKey
+	}
+
+	return sv.private.Sign(rand.Reader, data, nil)
+}
+
+// Verify verifies the `sig` value passed in against `data`.
+func (sv *MLDSASignerVerifier) Verify(_ context.Context, data []byte, sig []byte) error {
+	if err := mldsa.Verify(sv.public, data, sig, nil); err != nil {
+		return ErrSignatureVerificationFailed
+	}
+	return nil
+}
+
+// KeyID returns the identifier of the key used to create the
+// MLDSASignerVerifier instance.
+func (sv *MLDSASignerVerifier) KeyID() (string, error) {
+	return sv.keyID, nil
+}
+
+// Public returns the public portion of the key used to create the
+// MLDSASignerVerifier instance.
+func (sv *MLDSASignerVerifier) Public() crypto.PublicKey {
+	return sv.public
+}
+
+func isMLDSAKeyType(keyType string) bool {
+	_, ok := mldsaParameters[keyType]
+	return ok
+}
+
+// mldsaKeyType returns the keytype of ML-DSA keys with the given parameters.
+func mldsaKeyType(params mldsa.Parameters) (string, error) {
+	for keyType, p := range mldsaParameters {
+		if p == params {
+			return keyType, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnknownKeyType, params)
+}
+
+// newSSLibKeyFromMLDSAKey is used by LoadKey for parsed ML-DSA keys. The keyid
+// is set by the caller.
+func newSSLibKeyFromMLDSAKey(pemBlock *pem.Block, rawKey any) (*SSLibKey, error) {
+	var public *mldsa.PublicKey
+	var private string
+	switch k := rawKey.(type) {
+	case *mldsa.PublicKey:
+		public = k
+	case *mldsa.PrivateKey:
+		public = k.PublicKey()
+		private = strings.TrimSpace(string(generatePEMBlock(pemBlock.Bytes, PrivateKeyPEM)))
+	default:
+		return nil, ErrUnknownKeyType
+	}
+
+	keyType, err := mldsaKeyType(public.Parameters())
+	if err != nil {
+		return nil, err
+	}
+	pubKeyBytes, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SSLibKey{
+		KeyIDHashAlgorithms: KeyIDHashAlgorithms,
+		KeyType:             keyType,
+		KeyVal: KeyVal{
+			Public:  strings.TrimSpace(string(generatePEMBlock(pubKeyBytes, PublicKeyPEM))),
+			Private: private,
+		},
+		Scheme: keyType,
+	}, nil
+}
+
+// parseMLDSAPublicKey is used by Validate to check the public portion of
+// ML-DSA keys.
+func (k *SSLibKey) parseMLDSAPublicKey() (crypto.PublicKey, error) {
+	_, parsedKey, err := decodeAndParsePEM([]byte(k.KeyVal.Public))
+	if err != nil {
+		return nil, &KeyValidationError{Field: "keyval.public", Err: fmt.Errorf("%w: %w", ErrMalformedKey, err)}
+	}
+
+	public, ok := parsedKey.(*mldsa.PublicKey)
+	if !ok {
+		return nil, &KeyValidationError{Field: "keyval.public", Err: fmt.Errorf("%w: expected an ML-DSA public key", ErrMalformedKey)}
+	}
+	if public.Parameters() != mldsaParameters[k.KeyType] {
+		return nil, &KeyValidationError{Field: "keytype", Err: fmt.Errorf("%w: %q for %s key", ErrSchemeMismatch, k.KeyType, public.Parameters())}
+	}
+	return public, nil
+}
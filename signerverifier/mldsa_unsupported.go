@@ -0,0 +1,23 @@
+//go:build !go1.27
+
+package signerverifier
+
+import (
+	"crypto"
+	"encoding/pem"
+)
+
+// ML-DSA requires crypto/mldsa, which was added in Go 1.27. With earlier
+// versions, ML-DSA keys are rejected as unknown key types.
+
+func isMLDSAKeyType(string) bool {
+	return false
+}
+
+func newSSLibKeyFromMLDSAKey(*pem.Block, any) (*SSLibKey, error) {
+	return nil, ErrUnknownKeyType
+}
+
+func (k *SSLibKey) parseMLDSAPublicKey() (crypto.PublicKey, error) {
+	return nil, ErrUnknownKeyType
+}
@@ -0,0 +1,292 @@
+package signerverifier
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/sha1" //nolint:gosec // hmacWithSHA1 is the PBKDF2 default PRF
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/secure-systems-lab/go-securesystemslib/encrypted"
+	"golang.org/x/crypto/ssh"
+)
+
+const EncryptedPrivateKeyPEM = "ENCRYPTED PRIVATE KEY"
+
+var (
+	ErrPassphraseRequired       = errors.New("key is encrypted, a passphrase is required")
+	ErrIncorrectPassphrase      = errors.New("unable to decrypt key, incorrect passphrase")
+	ErrUnsupportedKeyEncryption = errors.New("unsupported private key encryption")
+)
+
+var (
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+// maxPBKDF2Iterations bounds the work an attacker controlled key file can
+// cause during decryption.
+const maxPBKDF2Iterations = 10_000_000
+
+// LoadKeyWithPassphrase returns an SSLibKey object when provided an encrypted
+// private key and its passphrase. The following encodings are supported:
+//
+//   - PEM encoded PKCS8 keys encrypted with PBES2, e.g. as created by
+//     `openssl pkcs8 -topk8`
+//   - encrypted OpenSSH private keys
+//   - keys encrypted with the encrypted package, e.g. by
+//     SSLibKey.MarshalEncrypted, that hold either an SSLibKey in the
+//     securesystemslib JSON format or any key LoadKey accepts
+//
+// The keyid of a decrypted SSLibKey is checked with VerifyKeyID, or assigned
+// if missing. Unencrypted keys are loaded as with LoadKey.
+func LoadKeyWithPassphrase(keyBytes []byte, passphrase []byte) (*SSLibKey, error) {
+	if block, _ := pem.Decode(keyBytes); block != nil {
+		switch block.Type {
+		case EncryptedPrivateKeyPEM:
+			privKeyBytes, err := decryptPKCS8PrivateKey(block.Bytes, passphrase)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load encrypted key: %w", err)
+			}
+			return LoadKey(generatePEMBlock(privKeyBytes, PrivateKeyPEM))
+		case OpenSSHPrivateKeyPEM:
+			return LoadSSHKey(keyBytes, passphrase)
+		}
+		return LoadKey(keyBytes)
+	}
+
+	if !isEncryptedBlob(keyBytes) {
+		return LoadKey(keyBytes)
+	}
+
+	plaintext, err := encrypted.Decrypt(keyBytes, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load encrypted key: %w", err)
+	}
+
+	key := &SSLibKey{}
+	if err := json.Unmarshal(plaintext, key); err != nil {
+		return LoadKey(plaintext)
+	}
+	if len(key.KeyVal.Private) == 0 {
+		return nil, ErrNotPrivateKey
+	}
+	if len(key.KeyID) == 0 {
+		keyID, err := calculateKeyID(key)
+		if err != nil {
+			return nil, err
+		}
+		key.KeyID = keyID
+	} else if err := key.VerifyKeyID(); err != nil {
+		return nil, fmt.Errorf("unable to load encrypted key: %w", err)
+	}
+
+	return key, nil
+}
+
+// MarshalEncrypted returns the key, including its private portion, encrypted
+// with the passphrase using the encrypted package and the KDF parameters of
+// the given strength. The result can be loaded with LoadKeyWithPassphrase and
+// preserves the key's scheme and keyid.
+func (k *SSLibKey) MarshalEncrypted(passphrase []byte, kdfLevel encrypted.KDFParameterStrength) ([]byte, error) {
+	if len(k.KeyVal.Private) == 0 {
+		return nil, ErrNotPrivateKey
+	}
+
+	return encrypted.MarshalWithCustomKDFParameters(k, passphrase, kdfLevel)
+}
+
+// loadKeyBytes returns an SSLibKey object when provided a key in the
+// securesystemslib JSON format or any encoding accepted by LoadKey or
+// LoadKeyWithPassphrase. passphraseFunc is only called for encrypted keys; if
+// it is nil, ErrPassphraseRequired is returned for them.
+func loadKeyBytes(keyBytes []byte, passphraseFunc func() ([]byte, error)) (*SSLibKey, error) {
+	if !isEncryptedBlob(keyBytes) {
+		var key *SSLibKey
+		var err error
+		if json.Valid(keyBytes) {
+			key, err = LoadSSLibKeyStrict(keyBytes)
+		} else {
+			key, err = LoadKey(keyBytes)
+		}
+
+		var missingErr *ssh.PassphraseMissingError
+		if !errors.Is(err, ErrPassphraseRequired) && !errors.As(err, &missingErr) {
+			return key, err
+		}
+	}
+
+	if passphraseFunc == nil {
+		return nil, ErrPassphraseRequired
+	}
+	passphrase, err := passphraseFunc()
+	if err != nil {
+		return nil, err
+	}
+	return LoadKeyWithPassphrase(keyBytes, passphrase)
+}
+
+// isEncryptedKey reports whether keyBytes holds a key in one of the encrypted
+// encodings accepted by LoadKeyWithPassphrase.
+func isEncryptedKey(keyBytes []byte) bool {
+	if block, _ := pem.Decode(keyBytes); block != nil {
+		switch block.Type {
+		case EncryptedPrivateKeyPEM:
+			return true
+		case OpenSSHPrivateKeyPEM:
+			var missingErr *ssh.PassphraseMissingError
+			_, err := ssh.ParseRawPrivateKey(keyBytes)
+			return errors.As(err, &missingErr)
+		}
+		return false
+	}
+
+	return isEncryptedBlob(keyBytes)
+}
+
+// isEncryptedBlob reports whether keyBytes is a JSON document in the format
+// produced by the encrypted package.
+func isEncryptedBlob(keyBytes []byte) bool {
+	var blob struct {
+		KDF        json.RawMessage `json:"kdf"`
+		Cipher     json.RawMessage `json:"cipher"`
+		Ciphertext json.RawMessage `json:"ciphertext"`
+	}
+	if err := json.Unmarshal(bytes.TrimSpace(keyBytes), &blob); err != nil {
+		return false
+	}
+	return blob.KDF != nil && blob.Cipher != nil && blob.Ciphertext != nil
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     algorithmIdentifier
+	EncryptedData []byte
+}
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc algorithmIdentifier
+	EncryptionScheme  algorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                 `asn1:"optional"`
+	PRF            algorithmIdentifier `asn1:"optional"`
+}
+
+// decryptPKCS8PrivateKey decrypts a DER encoded PKCS8 EncryptedPrivateKeyInfo
+// protected with PBES2 (RFC 8018), using PBKDF2 and AES-CBC, and returns the
+// DER encoded PKCS8 PrivateKeyInfo.
+func decryptPKCS8PrivateKey(der []byte, passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, ErrPassphraseRequired
+	}
+
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyEncryption, info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, err
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyEncryption, params.KeyDerivationFunc.Algorithm)
+	}
+
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, err
+	}
+	if kdfParams.IterationCount < 1 || kdfParams.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("%w: %d PBKDF2 iterations", ErrUnsupportedKeyEncryption, kdfParams.IterationCount)
+	}
+
+	var prf func() hash.Hash
+	switch {
+	case len(kdfParams.PRF.Algorithm) == 0, kdfParams.PRF.Algorithm.Equal(oidHMACSHA1):
+		prf = sha1.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACSHA256):
+		prf = sha256.New
+	case kdfParams.PRF.Algorithm.Equal(oidHMACSHA384):
+		prf = sha512.New384
+	case kdfParams.PRF.Algorithm.Equal(oidHMACSHA512):
+		prf = sha512.New
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyEncryption, kdfParams.PRF.Algorithm)
+	}
+
+	var keyLength int
+	switch {
+	case params.EncryptionScheme.Algorithm.Equal(oidAES128CBC):
+		keyLength = 16
+	case params.EncryptionScheme.Algorithm.Equal(oidAES192CBC):
+		keyLength = 24
+	case params.EncryptionScheme.Algorithm.Equal(oidAES256CBC):
+		keyLength = 32
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedKeyEncryption, params.EncryptionScheme.Algorithm)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("%w: invalid IV", ErrUnsupportedKeyEncryption)
+	}
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("%w: invalid ciphertext length", ErrUnsupportedKeyEncryption)
+	}
+
+	key, err := pbkdf2.Key(prf, string(passphrase), kdfParams.Salt, kdfParams.IterationCount, keyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, info.EncryptedData)
+
+	// A wrong passphrase almost always results in invalid padding or a
+	// plaintext that is not a PKCS8 structure.
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize || !bytes.Equal(plaintext[len(plaintext)-padding:], bytes.Repeat([]byte{byte(padding)}, padding)) {
+		return nil, ErrIncorrectPassphrase
+	}
+	plaintext = plaintext[:len(plaintext)-padding]
+
+	if _, err := parsePEMKey(plaintext); err != nil {
+		return nil, ErrIncorrectPassphrase
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,143 @@
+package signerverifier
+
+import (
+	_ "embed"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/encrypted"
+	"github.com/stretchr/testify/assert"
+)
+
+//go:embed test-data/rsa-test-key-encrypted
+var rsaEncryptedPrivateKey []byte
+
+//go:embed test-data/ecdsa-test-key-pem-encrypted
+var ecdsaEncryptedPrivateKey []byte
+
+//go:embed test-data/ed25519-test-key-pem-encrypted
+var ed25519EncryptedPrivateKey []byte
+
+//go:embed test-data/ed25519-test-key-pem-encrypted.json
+var ed25519EncryptedBlobPrivateKey []byte
+
+var testPassphrase = []byte("test passphrase")
+
+func TestLoadKeyWithPassphrase(t *testing.T) {
+	tests := map[string]struct {
+		keyBytes         []byte
+		expectedKeyBytes []byte
+	}{
+		"RSA PKCS8 key with AES-128 and HMAC-SHA1": {
+			keyBytes:         rsaEncryptedPrivateKey,
+			expectedKeyBytes: rsaPrivateKey,
+		},
+		"ECDSA PKCS8 key with AES-256 and HMAC-SHA256": {
+			keyBytes:         ecdsaEncryptedPrivateKey,
+			expectedKeyBytes: ecdsaPrivateKey,
+		},
+		"ED25519 PKCS8 key with AES-192 and HMAC-SHA512": {
+			keyBytes:         ed25519EncryptedPrivateKey,
+			expectedKeyBytes: ed25519PrivateKey,
+		},
+		"ED25519 key encrypted with the encrypted package": {
+			keyBytes:         ed25519EncryptedBlobPrivateKey,
+			expectedKeyBytes: ed25519PrivateKey,
+		},
+		"unencrypted key": {
+			keyBytes:         ecdsaPrivateKey,
+			expectedKeyBytes: ecdsaPrivateKey,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			expectedKey, err := LoadKey(test.expectedKeyBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key, err := LoadKeyWithPassphrase(test.keyBytes, testPassphrase)
+			assert.Nil(t, err)
+			assert.Equal(t, expectedKey.KeyID, key.KeyID)
+			assert.Equal(t, expectedKey.KeyVal.Public, key.KeyVal.Public)
+
+			expectedPrivate, err := expectedKey.MarshalPrivateKeyPEM()
+			assert.Nil(t, err)
+			private, err := key.MarshalPrivateKeyPEM()
+			assert.Nil(t, err)
+			assert.Equal(t, expectedPrivate, private)
+		})
+	}
+
+	t.Run("encrypted OpenSSH key", func(t *testing.T) {
+		expectedKey, err := LoadSSHKey(ed25519OpenSSHEncryptedPrivateKey, testPassphrase)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key, err := LoadKeyWithPassphrase(ed25519OpenSSHEncryptedPrivateKey, testPassphrase)
+		assert.Nil(t, err)
+		assert.Equal(t, expectedKey, key)
+	})
+
+	t.Run("incorrect passphrase", func(t *testing.T) {
+		_, err := LoadKeyWithPassphrase(ecdsaEncryptedPrivateKey, []byte("incorrect passphrase"))
+		assert.ErrorIs(t, err, ErrIncorrectPassphrase)
+
+		_, err = LoadKeyWithPassphrase(ed25519EncryptedBlobPrivateKey, []byte("incorrect passphrase"))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("missing passphrase", func(t *testing.T) {
+		_, err := LoadKeyWithPassphrase(ecdsaEncryptedPrivateKey, nil)
+		assert.ErrorIs(t, err, ErrPassphraseRequired)
+
+		_, err = LoadKey(ecdsaEncryptedPrivateKey)
+		assert.ErrorIs(t, err, ErrPassphraseRequired)
+	})
+}
+
+func TestMarshalEncrypted(t *testing.T) {
+	key, err := LoadKey(rsaPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The scheme and keyid must survive the round trip.
+	key.Scheme = RSAPKCS1v15SHA256KeyScheme
+	key.KeyID, err = key.CalculateKeyID(KeyIDHashAlgorithmSHA512)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encryptedKey, err := key.MarshalEncrypted(testPassphrase, encrypted.Legacy)
+	assert.Nil(t, err)
+	assert.NotContains(t, string(encryptedKey), "PRIVATE KEY")
+
+	decryptedKey, err := LoadKeyWithPassphrase(encryptedKey, testPassphrase)
+	assert.Nil(t, err)
+	assert.Equal(t, key, decryptedKey)
+
+	_, err = LoadKeyWithPassphrase(encryptedKey, []byte("incorrect passphrase"))
+	assert.NotNil(t, err)
+
+	t.Run("tampered keyid", func(t *testing.T) {
+		key := *key
+		key.KeyID = "custom-keyid"
+
+		encryptedKey, err := key.MarshalEncrypted(testPassphrase, encrypted.Legacy)
+		assert.Nil(t, err)
+
+		_, err = LoadKeyWithPassphrase(encryptedKey, testPassphrase)
+		assert.ErrorIs(t, err, ErrKeyIDMismatch)
+	})
+
+	t.Run("public key", func(t *testing.T) {
+		key, err := LoadKey(rsaPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = key.MarshalEncrypted(testPassphrase, encrypted.Legacy)
+		assert.ErrorIs(t, err, ErrNotPrivateKey)
+	})
+}
@@ -0,0 +1,18 @@
+/*
+Package pkcs11 implements a dsse.SignerVerifier for keys held in a PKCS#11
+token, such as a hardware security module. Private keys never leave the
+token: data is hashed locally and only the digest is signed by the token.
+ECDSA P-256 and P-384 keys and RSA keys with the RSA-PSS schemes are
+supported.
+
+Keys are addressed by the label of their token and their CKA_ID or
+CKA_LABEL, either with a Config or with a PKCS#11 URI as defined in RFC 7512.
+Importing the package registers the "pkcs11" scheme with
+signerverifier.SignerFromURI:
+
+	pkcs11:token=release;id=%01?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234
+
+The package loads the PKCS#11 module with cgo and is empty when cgo is
+disabled.
+*/
+package pkcs11
@@ -0,0 +1,441 @@
+//go:build cgo
+
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/secure-systems-lab/go-securesystemslib/signerverifier"
+)
+
+var (
+	ErrModuleNotLoaded = errors.New("unable to load PKCS#11 module")
+	ErrTokenNotFound   = errors.New("PKCS#11 token not found")
+	ErrKeyNotFound     = errors.New("PKCS#11 key not found")
+	ErrAmbiguousKey    = errors.New("PKCS#11 key reference matches more than one key")
+)
+
+var (
+	oidNamedCurveP256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}
+	oidNamedCurveP384 = asn1.ObjectIdentifier{1, 3, 132, 0, 34}
+)
+
+// mechanism describes how a scheme's digest is signed by the token.
+type mechanism struct {
+	hash          crypto.Hash
+	mechanism     uint
+	hashMechanism uint
+	mgf           uint
+}
+
+var mechanisms = map[string]mechanism{
+	signerverifier.ECDSAKeyScheme:        {hash: crypto.SHA256, mechanism: pkcs11.CKM_ECDSA},
+	signerverifier.ECDSAP384KeyScheme:    {hash: crypto.SHA384, mechanism: pkcs11.CKM_ECDSA},
+	signerverifier.RSAKeyScheme:          {hash: crypto.SHA256, mechanism: pkcs11.CKM_RSA_PKCS_PSS, hashMechanism: pkcs11.CKM_SHA256, mgf: pkcs11.CKG_MGF1_SHA256},
+	signerverifier.RSAPSSSHA384KeyScheme: {hash: crypto.SHA384, mechanism: pkcs11.CKM_RSA_PKCS_PSS, hashMechanism: pkcs11.CKM_SHA384, mgf: pkcs11.CKG_MGF1_SHA384},
+	signerverifier.RSAPSSSHA512KeyScheme: {hash: crypto.SHA512, mechanism: pkcs11.CKM_RSA_PKCS_PSS, hashMechanism: pkcs11.CKM_SHA512, mgf: pkcs11.CKG_MGF1_SHA512},
+}
+
+// Config addresses a key in a PKCS#11 token.
+type Config struct {
+	// ModulePath is the path of the PKCS#11 module (shared library) of the
+	// token.
+	ModulePath string
+
+	// TokenLabel is the label of the token holding the key.
+	TokenLabel string
+
+	// KeyID and KeyLabel are the CKA_ID and CKA_LABEL of the key. At least
+	// one must be set, and together they must match exactly one key.
+	KeyID    []byte
+	KeyLabel string
+
+	// PIN is the user PIN of the token.
+	PIN string
+
+	// Scheme is the securesystemslib scheme of the key. It defaults to
+	// ecdsa-sha2-nistp256 or ecdsa-sha2-nistp384 for ECDSA keys, depending
+	// on the curve, and to rsassa-pss-sha256 for RSA keys.
+	Scheme string
+}
+
+// SignerVerifier is a dsse.SignerVerifier compliant interface to sign with
+// keys held in a PKCS#11 token. It holds a session with the token until
+// Close is called.
+type SignerVerifier struct {
+	modulePath string
+	mechanism  mechanism
+	key        *signerverifier.SSLibKey
+	verifier   dsse.Verifier
+
+	// PKCS#11 sessions must not be used concurrently.
+	mu         sync.Mutex
+	ctx        *pkcs11.Ctx
+	session    pkcs11.SessionHandle
+	privateKey pkcs11.ObjectHandle
+}
+
+// New opens a session with the token and creates a SignerVerifier for the
+// key addressed by config.
+func New(config Config) (*SignerVerifier, error) {
+	if len(config.KeyID) == 0 && config.KeyLabel == "" {
+		return nil, fmt.Errorf("unable to create PKCS#11 signerverifier: %w: key id or label required", ErrKeyNotFound)
+	}
+
+	ctx, err := openModule(config.ModulePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create PKCS#11 signerverifier: %w", err)
+	}
+
+	sv, err := newSignerVerifier(ctx, config)
+	if err != nil {
+		closeModule(config.ModulePath)
+		return nil, fmt.Errorf("unable to create PKCS#11 signerverifier: %w", err)
+	}
+	return sv, nil
+}
+
+func newSignerVerifier(ctx *pkcs11.Ctx, config Config) (*SignerVerifier, error) {
+	slot, err := findToken(ctx, config.TokenLabel)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return nil, err
+	}
+
+	sv, err := func() (*SignerVerifier, error) {
+		// The login state is shared by all sessions with the token.
+		if err := ctx.Login(session, pkcs11.CKU_USER, config.PIN); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN)) {
+			return nil, err
+		}
+
+		privateKey, err := findKey(ctx, session, pkcs11.CKO_PRIVATE_KEY, config)
+		if err != nil {
+			return nil, err
+		}
+		publicKeyHandle, err := findKey(ctx, session, pkcs11.CKO_PUBLIC_KEY, config)
+		if err != nil {
+			return nil, err
+		}
+		public, err := publicKey(ctx, session, publicKeyHandle)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := sslibKey(public, config.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		verifier, err := signerverifier.NewSignerVerifierFromSSLibKey(key)
+		if err != nil {
+			return nil, err
+		}
+
+		return &SignerVerifier{
+			modulePath: config.ModulePath,
+			mechanism:  mechanisms[key.Scheme],
+			key:        key,
+			verifier:   verifier,
+			ctx:        ctx,
+			session:    session,
+			privateKey: privateKey,
+		}, nil
+	}()
+	if err != nil {
+		ctx.CloseSession(session) //nolint:errcheck
+		return nil, err
+	}
+	return sv, nil
+}
+
+// Sign creates a signature for `data`.
+func (sv *SignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
+	h := sv.mechanism.hash.New()
+	h.Write(data)
+	digest := h.Sum(nil)
+
+	var params any
+	if sv.mechanism.mechanism == pkcs11.CKM_RSA_PKCS_PSS {
+		params = pkcs11.NewPSSParams(sv.mechanism.hashMechanism, sv.mechanism.mgf, uint(sv.mechanism.hash.Size()))
+	}
+
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+
+	if sv.ctx == nil {
+		return nil, fmt.Errorf("unable to sign with PKCS#11 key: signerverifier is closed")
+	}
+	if err := sv.ctx.SignInit(sv.session, []*pkcs11.Mechanism{pkcs11.NewMechanism(sv.mechanism.mechanism, params)}, sv.privateKey); err != nil {
+		return nil, fmt.Errorf("unable to sign with PKCS#11 key: %w", err)
+	}
+	signature, err := sv.ctx.Sign(sv.session, digest)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign with PKCS#11 key: %w", err)
+	}
+
+	if sv.mechanism.mechanism == pkcs11.CKM_ECDSA {
+		// PKCS#11 encodes ECDSA signatures as r || s rather than ASN.1.
+		half := len(signature) / 2
+		return asn1.Marshal(struct {
+			R *big.Int
+			S *big.Int
+		}{
+			R: new(big.Int).SetBytes(signature[:half]),
+			S: new(big.Int).SetBytes(signature[half:]),
+		})
+	}
+	return signature, nil
+}
+
+// Verify verifies the `sig` value passed in against `data`.
+func (sv *SignerVerifier) Verify(ctx context.Context, data []byte, sig []byte) error {
+	return sv.verifier.Verify(ctx, data, sig)
+}
+
+// KeyID returns the identifier of the key.
+func (sv *SignerVerifier) KeyID() (string, error) {
+	return sv.key.KeyID, nil
+}
+
+// Public returns the public portion of the key.
+func (sv *SignerVerifier) Public() crypto.PublicKey {
+	return sv.verifier.Public()
+}
+
+// SSLibKey returns the public portion of the key as an SSLibKey, e.g. to add
+// it to TUF or in-toto metadata.
+func (sv *SignerVerifier) SSLibKey() *signerverifier.SSLibKey {
+	return sv.key.PublicOnly()
+}
+
+// Close closes the session with the token. The SignerVerifier can still
+// verify signatures, but can no longer sign.
+func (sv *SignerVerifier) Close() error {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+
+	if sv.ctx == nil {
+		return nil
+	}
+	err := sv.ctx.CloseSession(sv.session)
+	sv.ctx = nil
+	closeModule(sv.modulePath)
+	return err
+}
+
+// module is a PKCS#11 module shared by all SignerVerifiers using it, as a
+// module can only be initialized once per process.
+type module struct {
+	ctx  *pkcs11.Ctx
+	refs int
+}
+
+var (
+	modulesMu sync.Mutex
+	modules   = map[string]*module{}
+)
+
+func openModule(path string) (*pkcs11.Ctx, error) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	if m, ok := modules[path]; ok {
+		m.refs++
+		return m.ctx, nil
+	}
+
+	ctx := pkcs11.New(path)
+	if ctx == nil {
+		return nil, fmt.Errorf("%w: %s", ErrModuleNotLoaded, path)
+	}
+	if err := ctx.Initialize(); err != nil && !errors.Is(err, pkcs11.Error(pkcs11.CKR_CRYPTOKI_ALREADY_INITIALIZED)) {
+		ctx.Destroy()
+		return nil, fmt.Errorf("%w: %s: %w", ErrModuleNotLoaded, path, err)
+	}
+
+	modules[path] = &module{ctx: ctx, refs: 1}
+	return ctx, nil
+}
+
+func closeModule(path string) {
+	modulesMu.Lock()
+	defer modulesMu.Unlock()
+
+	m, ok := modules[path]
+	if !ok {
+		return
+	}
+	m.refs--
+	if m.refs > 0 {
+		return
+	}
+
+	m.ctx.Finalize() //nolint:errcheck
+	m.ctx.Destroy()
+	delete(modules, path)
+}
+
+func findToken(ctx *pkcs11.Ctx, label string) (uint, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			return 0, err
+		}
+		if info.Label == label {
+			return slot, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: %q", ErrTokenNotFound, label)
+}
+
+func findKey(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, class uint, config Config) (pkcs11.ObjectHandle, error) {
+	template := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_CLASS, class)}
+	if len(config.KeyID) > 0 {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_ID, config.KeyID))
+	}
+	if config.KeyLabel != "" {
+		template = append(template, pkcs11.NewAttribute(pkcs11.CKA_LABEL, config.KeyLabel))
+	}
+
+	if err := ctx.FindObjectsInit(session, template); err != nil {
+		return 0, err
+	}
+	objects, _, err := ctx.FindObjects(session, 2)
+	if finalErr := ctx.FindObjectsFinal(session); err == nil {
+		err = finalErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	switch len(objects) {
+	case 0:
+		return 0, fmt.Errorf("%w: id %x, label %q", ErrKeyNotFound, config.KeyID, config.KeyLabel)
+	case 1:
+		return objects[0], nil
+	default:
+		return 0, fmt.Errorf("%w: id %x, label %q", ErrAmbiguousKey, config.KeyID, config.KeyLabel)
+	}
+}
+
+func publicKey(ctx *pkcs11.Ctx, session pkcs11.SessionHandle, object pkcs11.ObjectHandle) (crypto.PublicKey, error) {
+	attributes, err := ctx.GetAttributeValue(session, object, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	switch keyType := ulong(attributes[0].Value); keyType {
+	case pkcs11.CKK_EC:
+		attributes, err := ctx.GetAttributeValue(session, object, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var curveOID asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(attributes[0].Value, &curveOID); err != nil {
+			return nil, fmt.Errorf("unable to parse EC parameters: %w", err)
+		}
+		var curve elliptic.Curve
+		switch {
+		case curveOID.Equal(oidNamedCurveP256):
+			curve = elliptic.P256()
+		case curveOID.Equal(oidNamedCurveP384):
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("%w: curve %s", signerverifier.ErrUnknownKeyType, curveOID)
+		}
+
+		// CKA_EC_POINT is a DER octet string, although some modules omit
+		// the encoding.
+		point := attributes[1].Value
+		var encodedPoint []byte
+		if rest, err := asn1.Unmarshal(point, &encodedPoint); err == nil && len(rest) == 0 {
+			point = encodedPoint
+		}
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+
+	case pkcs11.CKK_RSA:
+		attributes, err := ctx.GetAttributeValue(session, object, []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_MODULUS, nil),
+			pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, nil),
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(attributes[0].Value),
+			E: int(new(big.Int).SetBytes(attributes[1].Value).Int64()),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("%w: PKCS#11 key type %d", signerverifier.ErrUnknownKeyType, keyType)
+	}
+}
+
+// ulong decodes a CK_ULONG attribute, which is in native byte order.
+func ulong(b []byte) uint64 {
+	switch len(b) {
+	case 4:
+		return uint64(binary.NativeEndian.Uint32(b))
+	case 8:
+		return binary.NativeEndian.Uint64(b)
+	default:
+		return 0
+	}
+}
+
+// sslibKey returns public as an SSLibKey with the given scheme, or the
+// default scheme of the key if empty.
+func sslibKey(public crypto.PublicKey, scheme string) (*signerverifier.SSLibKey, error) {
+	publicBytes, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	key, err := signerverifier.LoadKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicBytes}))
+	if err != nil {
+		return nil, err
+	}
+
+	if scheme == "" || scheme == key.Scheme {
+		return key, nil
+	}
+
+	// Only RSA keys can be used with several schemes.
+	if m, ok := mechanisms[scheme]; !ok || key.KeyType != signerverifier.RSAKeyType || m.mechanism != pkcs11.CKM_RSA_PKCS_PSS {
+		return nil, fmt.Errorf("%w: %q for %s key", signerverifier.ErrUnknownScheme, scheme, key.KeyType)
+	}
+	key.Scheme = scheme
+	key.KeyID, err = key.CalculateKeyID(signerverifier.KeyIDHashAlgorithmSHA256)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
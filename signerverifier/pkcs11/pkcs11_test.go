@@ -0,0 +1,404 @@
+//go:build cgo
+
+package pkcs11
+
+import (
+	"context"
+	"encoding/asn1"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/miekg/pkcs11"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/secure-systems-lab/go-securesystemslib/signerverifier"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testTokenLabel = "go-securesystemslib"
+	testSOPIN      = "12345678"
+	testPIN        = "1234"
+)
+
+// softHSMModulePaths are the locations of the SoftHSMv2 module on common
+// systems. SOFTHSM2_MODULE takes precedence.
+var softHSMModulePaths = []string{
+	"/usr/lib/softhsm/libsofthsm2.so",
+	"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib/aarch64-linux-gnu/softhsm/libsofthsm2.so",
+	"/usr/lib64/pkcs11/libsofthsm2.so",
+	"/usr/local/lib/softhsm/libsofthsm2.so",
+	"/opt/homebrew/lib/softhsm/libsofthsm2.so",
+}
+
+// newTestToken initializes a SoftHSMv2 token in a temporary directory, with
+// an ECDSA P-256 key (id 01), an ECDSA P-384 key (id 02) and an RSA key (id
+// 03), labeled after their type. The test is skipped if SoftHSMv2 is not
+// installed.
+func newTestToken(t *testing.T) string {
+	t.Helper()
+
+	modulePath := os.Getenv("SOFTHSM2_MODULE")
+	if modulePath == "" {
+		for _, path := range softHSMModulePaths {
+			if _, err := os.Stat(path); err == nil {
+				modulePath = path
+				break
+			}
+		}
+	}
+	if modulePath == "" {
+		t.Skip("SoftHSMv2 is not installed, set SOFTHSM2_MODULE to the path of libsofthsm2.so")
+	}
+
+	dir := t.TempDir()
+	tokenDir := filepath.Join(dir, "tokens")
+	if err := os.Mkdir(tokenDir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	conf := filepath.Join(dir, "softhsm2.conf")
+	if err := os.WriteFile(conf, []byte("directories.tokendir = "+tokenDir+"\nobjectstore.backend = file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SOFTHSM2_CONF", conf)
+
+	ctx := pkcs11.New(modulePath)
+	if ctx == nil {
+		t.Fatalf("unable to load %s", modulePath)
+	}
+	defer ctx.Destroy()
+	if err := ctx.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Finalize() //nolint:errcheck
+
+	slots, err := ctx.GetSlotList(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.InitToken(slots[0], testSOPIN, testTokenLabel); err != nil {
+		t.Fatal(err)
+	}
+
+	// SoftHSMv2 moves initialized tokens to a new slot.
+	slot, err := findToken(ctx, testTokenLabel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.CloseSession(session) //nolint:errcheck
+
+	if err := ctx.Login(session, pkcs11.CKU_SO, testSOPIN); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.InitPIN(session, testPIN); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Logout(session); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctx.Login(session, pkcs11.CKU_USER, testPIN); err != nil {
+		t.Fatal(err)
+	}
+	defer ctx.Logout(session) //nolint:errcheck
+
+	generate := func(id byte, label string, mechanism uint, publicAttributes ...*pkcs11.Attribute) {
+		t.Helper()
+
+		publicTemplate := append([]*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_VERIFY, true),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, []byte{id}),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		}, publicAttributes...)
+		privateTemplate := []*pkcs11.Attribute{
+			pkcs11.NewAttribute(pkcs11.CKA_TOKEN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_PRIVATE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SENSITIVE, true),
+			pkcs11.NewAttribute(pkcs11.CKA_SIGN, true),
+			pkcs11.NewAttribute(pkcs11.CKA_ID, []byte{id}),
+			pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+		}
+		if _, _, err := ctx.GenerateKeyPair(session, []*pkcs11.Mechanism{pkcs11.NewMechanism(mechanism, nil)}, publicTemplate, privateTemplate); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ecParams := func(oid asn1.ObjectIdentifier) *pkcs11.Attribute {
+		params, err := asn1.Marshal(oid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, params)
+	}
+
+	generate(1, "ecdsa-p256", pkcs11.CKM_EC_KEY_PAIR_GEN, ecParams(oidNamedCurveP256))
+	generate(2, "ecdsa-p384", pkcs11.CKM_EC_KEY_PAIR_GEN, ecParams(oidNamedCurveP384))
+	generate(3, "rsa", pkcs11.CKM_RSA_PKCS_KEY_PAIR_GEN,
+		pkcs11.NewAttribute(pkcs11.CKA_MODULUS_BITS, 2048),
+		pkcs11.NewAttribute(pkcs11.CKA_PUBLIC_EXPONENT, []byte{1, 0, 1}),
+	)
+
+	return modulePath
+}
+
+func TestSignerVerifier(t *testing.T) {
+	modulePath := newTestToken(t)
+	message := []byte("test message")
+
+	config := func(keyID byte) Config {
+		return Config{
+			ModulePath: modulePath,
+			TokenLabel: testTokenLabel,
+			KeyID:      []byte{keyID},
+			PIN:        testPIN,
+		}
+	}
+
+	tests := map[string]struct {
+		config         Config
+		expectedScheme string
+	}{
+		"ECDSA P-256 key by id": {
+			config:         config(1),
+			expectedScheme: signerverifier.ECDSAKeyScheme,
+		},
+		"ECDSA P-384 key by id": {
+			config:         config(2),
+			expectedScheme: signerverifier.ECDSAP384KeyScheme,
+		},
+		"ECDSA P-256 key by label": {
+			config: Config{
+				ModulePath: modulePath,
+				TokenLabel: testTokenLabel,
+				KeyLabel:   "ecdsa-p256",
+				PIN:        testPIN,
+			},
+			expectedScheme: signerverifier.ECDSAKeyScheme,
+		},
+		"RSA key": {
+			config:         config(3),
+			expectedScheme: signerverifier.RSAKeyScheme,
+		},
+		"RSA key with RSA-PSS SHA-512": {
+			config: func() Config {
+				c := config(3)
+				c.Scheme = signerverifier.RSAPSSSHA512KeyScheme
+				return c
+			}(),
+			expectedScheme: signerverifier.RSAPSSSHA512KeyScheme,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sv, err := New(test.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sv.Close() //nolint:errcheck
+
+			key := sv.SSLibKey()
+			assert.Equal(t, test.expectedScheme, key.Scheme)
+			assert.Empty(t, key.KeyVal.Private)
+			keyID, err := sv.KeyID()
+			assert.Nil(t, err)
+			assert.Equal(t, key.KeyID, keyID)
+
+			es, err := dsse.NewEnvelopeSigner(sv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env, err := es.SignPayload(context.Background(), "application/vnd.dsse+json", message)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Signatures verify with the public key alone.
+			verifier, err := signerverifier.NewSignerVerifierFromSSLibKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev, err := dsse.NewEnvelopeVerifier(verifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			acceptedKeys, err := ev.Verify(context.Background(), env)
+			assert.Nil(t, err)
+			if assert.Len(t, acceptedKeys, 1) {
+				assert.Equal(t, key.KeyID, acceptedKeys[0].KeyID)
+			}
+		})
+	}
+
+	t.Run("concurrent signing", func(t *testing.T) {
+		sv, err := New(config(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sv.Close() //nolint:errcheck
+
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Go(func() {
+				sig, err := sv.Sign(context.Background(), message)
+				if assert.Nil(t, err) {
+					assert.Nil(t, sv.Verify(context.Background(), message, sig))
+				}
+			})
+		}
+		wg.Wait()
+	})
+
+	t.Run("closed", func(t *testing.T) {
+		sv, err := New(config(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := sv.Sign(context.Background(), message)
+		assert.Nil(t, err)
+		assert.Nil(t, sv.Close())
+		assert.Nil(t, sv.Close())
+
+		_, err = sv.Sign(context.Background(), message)
+		assert.NotNil(t, err)
+		assert.Nil(t, sv.Verify(context.Background(), message, sig))
+	})
+
+	errorTests := map[string]struct {
+		modify      func(*Config)
+		expectedErr error
+	}{
+		"unknown module": {
+			modify:      func(c *Config) { c.ModulePath = filepath.Join(t.TempDir(), "missing.so") },
+			expectedErr: ErrModuleNotLoaded,
+		},
+		"unknown token": {
+			modify:      func(c *Config) { c.TokenLabel = "unknown" },
+			expectedErr: ErrTokenNotFound,
+		},
+		"unknown key": {
+			modify:      func(c *Config) { c.KeyID = []byte{0xff} },
+			expectedErr: ErrKeyNotFound,
+		},
+		"no key reference": {
+			modify:      func(c *Config) { c.KeyID = nil },
+			expectedErr: ErrKeyNotFound,
+		},
+		"incorrect PIN": {
+			modify:      func(c *Config) { c.PIN = "0000" },
+			expectedErr: pkcs11.Error(pkcs11.CKR_PIN_INCORRECT),
+		},
+		"RSA scheme for ECDSA key": {
+			modify:      func(c *Config) { c.Scheme = signerverifier.RSAKeyScheme },
+			expectedErr: signerverifier.ErrUnknownScheme,
+		},
+		"RSA PKCS#1 v1.5 scheme": {
+			modify: func(c *Config) {
+				c.KeyID = []byte{3}
+				c.Scheme = signerverifier.RSAPKCS1v15SHA256KeyScheme
+			},
+			expectedErr: signerverifier.ErrUnknownScheme,
+		},
+	}
+
+	for name, test := range errorTests {
+		t.Run(name, func(t *testing.T) {
+			c := config(1)
+			test.modify(&c)
+			_, err := New(c)
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+
+	t.Run("URI", func(t *testing.T) {
+		p256, err := New(config(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pubKey := p256.SSLibKey()
+		assert.Nil(t, p256.Close())
+
+		base := "pkcs11:token=" + testTokenLabel + ";id=%01?module-path=" + url.QueryEscape(modulePath)
+
+		sv, err := signerverifier.SignerFromURI(context.Background(), base+"&pin-value="+testPIN, pubKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyID, err := sv.KeyID()
+		assert.Nil(t, err)
+		assert.Equal(t, pubKey.KeyID, keyID)
+		sig, err := sv.Sign(context.Background(), message)
+		assert.Nil(t, err)
+		assert.Nil(t, sv.Verify(context.Background(), message, sig))
+		assert.Nil(t, sv.(*SignerVerifier).Close())
+
+		// The PIN is obtained with the passphrase function.
+		sv, err = signerverifier.SignerFromURI(context.Background(), base, nil, signerverifier.WithPassphraseFunc(func(string) ([]byte, error) {
+			return []byte(testPIN), nil
+		}))
+		if assert.Nil(t, err) {
+			assert.Nil(t, sv.(*SignerVerifier).Close())
+		}
+
+		errPINRequired := errors.New("PIN required")
+		_, err = signerverifier.SignerFromURI(context.Background(), base, nil, signerverifier.WithPassphraseFunc(func(string) ([]byte, error) {
+			return nil, errPINRequired
+		}))
+		assert.ErrorIs(t, err, errPINRequired)
+
+		p384URI := "pkcs11:token=" + testTokenLabel + ";object=ecdsa-p384?module-path=" + url.QueryEscape(modulePath) + "&pin-value=" + testPIN
+		_, err = signerverifier.SignerFromURI(context.Background(), p384URI, pubKey)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestParseURI(t *testing.T) {
+	tests := map[string]struct {
+		uri            string
+		expectedConfig Config
+	}{
+		"token and id": {
+			uri: "pkcs11:token=release;id=%01%02?module-path=/usr/lib/softhsm/libsofthsm2.so&pin-value=1234",
+			expectedConfig: Config{
+				ModulePath: "/usr/lib/softhsm/libsofthsm2.so",
+				TokenLabel: "release",
+				KeyID:      []byte{1, 2},
+				PIN:        "1234",
+			},
+		},
+		"escaped labels": {
+			uri: "pkcs11:token=release%20keys;object=key%3B1;type=private?module-path=libsofthsm2.so",
+			expectedConfig: Config{
+				ModulePath: "libsofthsm2.so",
+				TokenLabel: "release keys",
+				KeyLabel:   "key;1",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			config, err := ParseURI(test.uri)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedConfig, config)
+		})
+	}
+
+	t.Run("missing module path", func(t *testing.T) {
+		_, err := ParseURI("pkcs11:token=release;id=%01")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("wrong scheme", func(t *testing.T) {
+		_, err := ParseURI("file:release")
+		assert.ErrorIs(t, err, signerverifier.ErrUnknownURIScheme)
+	})
+}
@@ -0,0 +1,110 @@
+//go:build cgo
+
+package pkcs11
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/secure-systems-lab/go-securesystemslib/signerverifier"
+)
+
+const URIScheme = "pkcs11"
+
+func init() {
+	signerverifier.RegisterSignerURIScheme(URIScheme, signerFromURI)
+}
+
+// ParseURI returns the Config for a PKCS#11 URI as defined in RFC 7512. The
+// token, object and id path attributes and the module-path and pin-value
+// query attributes are supported.
+func ParseURI(uri string) (Config, error) {
+	parsedURI, err := url.Parse(uri)
+	if err != nil {
+		return Config{}, fmt.Errorf("unable to parse PKCS#11 URI: %w", err)
+	}
+	return parseURI(parsedURI)
+}
+
+func parseURI(uri *url.URL) (Config, error) {
+	if uri.Scheme != URIScheme {
+		return Config{}, fmt.Errorf("unable to parse PKCS#11 URI: %w: %q", signerverifier.ErrUnknownURIScheme, uri.Scheme)
+	}
+
+	var config Config
+	for attribute := range strings.SplitSeq(uri.Opaque, ";") {
+		if attribute == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(attribute, "=")
+		value, err := url.PathUnescape(value)
+		if err != nil {
+			return Config{}, fmt.Errorf("unable to parse PKCS#11 URI: %w", err)
+		}
+
+		switch name {
+		case "token":
+			config.TokenLabel = value
+		case "object":
+			config.KeyLabel = value
+		case "id":
+			config.KeyID = []byte(value)
+		}
+	}
+
+	query := uri.Query()
+	config.ModulePath = query.Get("module-path")
+	config.PIN = query.Get("pin-value")
+
+	if config.ModulePath == "" {
+		return Config{}, fmt.Errorf("unable to parse PKCS#11 URI: module-path required")
+	}
+	return config, nil
+}
+
+// signerFromURI creates a SignerVerifier for a PKCS#11 URI. If the URI has no
+// pin-value, the PIN is obtained with the PassphraseFunc option.
+func signerFromURI(_ context.Context, uri *url.URL, pubKey *signerverifier.SSLibKey, opts *signerverifier.SignerURIOptions) (dsse.SignerVerifier, error) {
+	config, err := parseURI(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.PIN == "" && opts.PassphraseFunc != nil {
+		pin, err := opts.PassphraseFunc(uri.String())
+		if err != nil {
+			return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+		}
+		config.PIN = string(pin)
+	}
+	if pubKey != nil {
+		config.Scheme = pubKey.Scheme
+	}
+
+	sv, err := New(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+	}
+	if pubKey == nil {
+		return sv, nil
+	}
+
+	expected, err := signerverifier.NewSignerVerifierFromSSLibKey(pubKey.PublicOnly())
+	if err != nil {
+		sv.Close() //nolint:errcheck
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+	}
+	if !expected.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(sv.Public()) {
+		sv.Close() //nolint:errcheck
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, signerverifier.ErrKeyPairMismatch)
+	}
+
+	// Use the keyid the key is known by, which may have been computed with
+	// another algorithm.
+	sv.key = pubKey.PublicOnly()
+	return sv, nil
+}
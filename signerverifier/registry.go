@@ -0,0 +1,74 @@
+package signerverifier
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+// SignerVerifierFactory creates a dsse.SignerVerifier from an SSLibKey.
+type SignerVerifierFactory func(key *SSLibKey) (dsse.SignerVerifier, error)
+
+type keyTypeAndScheme struct {
+	keyType string
+	scheme  string
+}
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[keyTypeAndScheme]SignerVerifierFactory{}
+)
+
+func init() {
+	RegisterSignerVerifierFactory(ED25519KeyType, ED25519KeyType, func(key *SSLibKey) (dsse.SignerVerifier, error) {
+		return NewED25519SignerVerifierFromSSLibKey(key)
+	})
+
+	ecdsaFactory := func(key *SSLibKey) (dsse.SignerVerifier, error) {
+		return NewECDSASignerVerifierFromSSLibKey(key)
+	}
+	for scheme := range ecdsaSchemes {
+		RegisterSignerVerifierFactory(ECDSAKeyType, scheme, ecdsaFactory)
+		// python-securesystemslib also accepts the scheme as keytype for
+		// legacy ECDSA keys.
+		RegisterSignerVerifierFactory(scheme, scheme, ecdsaFactory)
+	}
+
+	rsaFactory := func(key *SSLibKey) (dsse.SignerVerifier, error) {
+		return NewRSASignerVerifierFromSSLibKey(key)
+	}
+	for scheme := range rsaSchemes {
+		RegisterSignerVerifierFactory(RSAKeyType, scheme, rsaFactory)
+	}
+}
+
+// RegisterSignerVerifierFactory registers the factory used by
+// NewSignerVerifierFromSSLibKey for keys with the given keytype and scheme.
+// Registering a factory for a combination that is already known replaces the
+// existing factory. This allows packages to add support for custom key types.
+func RegisterSignerVerifierFactory(keyType, scheme string, factory SignerVerifierFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	factories[keyTypeAndScheme{keyType: keyType, scheme: scheme}] = factory
+}
+
+// NewSignerVerifierFromSSLibKey creates a dsse.SignerVerifier for the key using
+// the factory registered for its keytype and scheme. ErrUnknownKeyType is
+// returned if no factory is registered for the combination.
+func NewSignerVerifierFromSSLibKey(key *SSLibKey) (dsse.SignerVerifier, error) {
+	if key == nil {
+		return nil, ErrInvalidKey
+	}
+
+	factoriesMu.RLock()
+	factory, ok := factories[keyTypeAndScheme{keyType: key.KeyType, scheme: key.Scheme}]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: keytype %q with scheme %q", ErrUnknownKeyType, key.KeyType, key.Scheme)
+	}
+
+	return factory(key)
+}
@@ -0,0 +1,153 @@
+package signerverifier
+
+import (
+	"context"
+	"crypto"
+	"errors"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+)
+
+type customSignerVerifier struct {
+	keyID string
+}
+
+func (sv *customSignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
+	return data, nil
+}
+
+func (sv *customSignerVerifier) Verify(_ context.Context, data []byte, sig []byte) error {
+	if string(data) != string(sig) {
+		return ErrSignatureVerificationFailed
+	}
+	return nil
+}
+
+func (sv *customSignerVerifier) KeyID() (string, error) {
+	return sv.keyID, nil
+}
+
+func (sv *customSignerVerifier) Public() crypto.PublicKey {
+	return "custom-public"
+}
+
+func TestNewSignerVerifierFromSSLibKey(t *testing.T) {
+	tests := map[string]struct {
+		keyBytes     []byte
+		expectedType dsse.SignerVerifier
+	}{
+		"RSA key": {
+			keyBytes:     rsaPrivateKey,
+			expectedType: &RSASignerVerifier{},
+		},
+		"ED25519 key": {
+			keyBytes:     ed25519PrivateKey,
+			expectedType: &ED25519SignerVerifier{},
+		},
+		"ECDSA key": {
+			keyBytes:     ecdsaPrivateKey,
+			expectedType: &ECDSASignerVerifier{},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, err := LoadKey(test.keyBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sv, err := NewSignerVerifierFromSSLibKey(key)
+			assert.Nil(t, err)
+			assert.IsType(t, test.expectedType, sv)
+
+			keyID, err := sv.KeyID()
+			assert.Nil(t, err)
+			assert.Equal(t, key.KeyID, keyID)
+
+			message := []byte("test message")
+			signature, err := sv.Sign(context.Background(), message)
+			assert.Nil(t, err)
+			assert.Nil(t, sv.Verify(context.Background(), message, signature))
+		})
+	}
+
+	t.Run("legacy ECDSA keytype", func(t *testing.T) {
+		key, err := LoadKey(ecdsaPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.KeyType = ECDSAKeyScheme
+
+		sv, err := NewSignerVerifierFromSSLibKey(key)
+		assert.Nil(t, err)
+		assert.IsType(t, &ECDSASignerVerifier{}, sv)
+	})
+
+	t.Run("unknown keytype and scheme", func(t *testing.T) {
+		key, err := LoadKey(ed25519PublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.Scheme = RSAKeyScheme
+
+		_, err = NewSignerVerifierFromSSLibKey(key)
+		assert.ErrorIs(t, err, ErrUnknownKeyType)
+	})
+
+	t.Run("nil key", func(t *testing.T) {
+		_, err := NewSignerVerifierFromSSLibKey(nil)
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+}
+
+// registerTestSignerVerifierFactory registers factory for the duration of the
+// test, restoring the previous registration afterwards.
+func registerTestSignerVerifierFactory(t *testing.T, keyType, scheme string, factory SignerVerifierFactory) {
+	t.Helper()
+
+	id := keyTypeAndScheme{keyType: keyType, scheme: scheme}
+	factoriesMu.RLock()
+	previous, ok := factories[id]
+	factoriesMu.RUnlock()
+
+	t.Cleanup(func() {
+		factoriesMu.Lock()
+		defer factoriesMu.Unlock()
+
+		if ok {
+			factories[id] = previous
+		} else {
+			delete(factories, id)
+		}
+	})
+	RegisterSignerVerifierFactory(keyType, scheme, factory)
+}
+
+func TestRegisterSignerVerifierFactory(t *testing.T) {
+	errFactory := errors.New("factory error")
+	registerTestSignerVerifierFactory(t, "custom", "custom-scheme", func(key *SSLibKey) (dsse.SignerVerifier, error) {
+		if len(key.KeyVal.Public) == 0 {
+			return nil, errFactory
+		}
+		return &customSignerVerifier{keyID: key.KeyID}, nil
+	})
+
+	key := &SSLibKey{
+		KeyType: "custom",
+		Scheme:  "custom-scheme",
+		KeyVal:  KeyVal{Public: "public"},
+		KeyID:   "custom-keyid",
+	}
+
+	sv, err := NewSignerVerifierFromSSLibKey(key)
+	assert.Nil(t, err)
+	keyID, err := sv.KeyID()
+	assert.Nil(t, err)
+	assert.Equal(t, "custom-keyid", keyID)
+
+	_, err = NewSignerVerifierFromSSLibKey(&SSLibKey{KeyType: "custom", Scheme: "custom-scheme"})
+	assert.ErrorIs(t, err, errFactory)
+}
@@ -0,0 +1,252 @@
+package signerverifier
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+var (
+	ErrRemoteKeyNotFound = errors.New("remote key not found")
+	ErrHashMismatch      = errors.New("hash algorithm does not match key scheme")
+)
+
+// RemoteKeyService is implemented by key management services that keep
+// private keys out of process, such as a cloud KMS or a dedicated signing
+// host. Keys are addressed by a key URI, whose format is defined by the
+// service.
+type RemoteKeyService interface {
+	// PublicKey returns the public portion of the key addressed by keyURI.
+	// ErrRemoteKeyNotFound is returned if the service has no such key.
+	PublicKey(ctx context.Context, keyURI string) (*SSLibKey, error)
+
+	// SignDigest signs a digest computed with hash, which must match the
+	// scheme of the key. For schemes that sign the full message, such as
+	// ed25519, hash is zero and digest is the message itself.
+	SignDigest(ctx context.Context, keyURI string, hash crypto.Hash, digest []byte) ([]byte, error)
+}
+
+// RemoteSignerVerifier is a dsse.SignerVerifier compliant interface to sign
+// with keys held by a RemoteKeyService. Data is hashed locally according to
+// the key's scheme, so only digests are sent to the service, and signatures
+// are verified locally with the public key.
+type RemoteSignerVerifier struct {
+	keyID    string
+	keyURI   string
+	hash     crypto.Hash
+	service  RemoteKeyService
+	verifier dsse.Verifier
+}
+
+// NewRemoteSignerVerifier creates a RemoteSignerVerifier for the key
+// addressed by keyURI, fetching its public portion from the service. The
+// keyid returned by the service must match the key, see VerifyKeyID; if it is
+// missing, the SHA-256 keyid is used.
+func NewRemoteSignerVerifier(ctx context.Context, service RemoteKeyService, keyURI string) (*RemoteSignerVerifier, error) {
+	if service == nil {
+		return nil, ErrInvalidKey
+	}
+
+	key, err := service.PublicKey(ctx, keyURI)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create remote signerverifier: %w", err)
+	}
+
+	keyID := key.KeyID
+	if keyID == "" {
+		keyID, err = calculateKeyID(key)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create remote signerverifier: %w", err)
+		}
+	} else if err := key.VerifyKeyID(); err != nil {
+		return nil, fmt.Errorf("unable to create remote signerverifier: %w", err)
+	}
+
+	opts, err := signerOptsForScheme(key.Scheme)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create remote signerverifier: %w", err)
+	}
+
+	verifier, err := NewSignerVerifierFromSSLibKey(key.PublicOnly())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create remote signerverifier: %w", err)
+	}
+
+	return &RemoteSignerVerifier{
+		keyID:    keyID,
+		keyURI:   keyURI,
+		hash:     opts.HashFunc(),
+		service:  service,
+		verifier: verifier,
+	}, nil
+}
+
+// Sign creates a signature for `data`.
+func (sv *RemoteSignerVerifier) Sign(ctx context.Context, data []byte) ([]byte, error) {
+	digest := data
+	if sv.hash != 0 {
+		digest = hashBeforeSigning(data, sv.hash.New())
+	}
+
+	return sv.service.SignDigest(ctx, sv.keyURI, sv.hash, digest)
+}
+
+// Verify verifies the `sig` value passed in against `data`.
+func (sv *RemoteSignerVerifier) Verify(ctx context.Context, data []byte, sig []byte) error {
+	return sv.verifier.Verify(ctx, data, sig)
+}
+
+// KeyID returns the identifier of the key used to create the
+// RemoteSignerVerifier instance.
+func (sv *RemoteSignerVerifier) KeyID() (string, error) {
+	return sv.keyID, nil
+}
+
+// Public returns the public portion of the key used to create the
+// RemoteSignerVerifier instance.
+func (sv *RemoteSignerVerifier) Public() crypto.PublicKey {
+	return sv.verifier.Public()
+}
+
+// LocalKeyService is a RemoteKeyService backed by SSLibKeys held in memory.
+// It is the backend of the reference signing server in the remote package,
+// and is also useful to test code written against RemoteKeyService.
+type LocalKeyService struct {
+	mu   sync.RWMutex
+	keys map[string]*SSLibKey
+}
+
+// NewLocalKeyService creates an empty LocalKeyService.
+func NewLocalKeyService() *LocalKeyService {
+	return &LocalKeyService{keys: map[string]*SSLibKey{}}
+}
+
+// LoadLocalKeyService creates a LocalKeyService from the private keys in dir,
+// addressed by their file name. Keys may be in the securesystemslib JSON
+// format or any encoding accepted by LoadKey. Hidden files and directories are
+// skipped.
+func LoadLocalKeyService(dir string) (*LocalKeyService, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	service := NewLocalKeyService()
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		contents, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var key *SSLibKey
+		if json.Valid(contents) {
+			key, err = LoadSSLibKeyStrict(contents)
+		} else {
+			key, err = LoadKey(contents)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to load key %s: %w", entry.Name(), err)
+		}
+
+		if err := service.AddKey(entry.Name(), key); err != nil {
+			return nil, fmt.Errorf("unable to load key %s: %w", entry.Name(), err)
+		}
+	}
+
+	return service, nil
+}
+
+// AddKey makes the private key available under keyURI, replacing any key
+// previously added under the same URI.
+func (s *LocalKeyService) AddKey(keyURI string, key *SSLibKey) error {
+	if key == nil {
+		return ErrInvalidKey
+	}
+	if _, err := key.privateCryptoKey(); err != nil {
+		return err
+	}
+	if _, err := signerOptsForScheme(key.Scheme); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[keyURI] = key
+	return nil
+}
+
+// KeyURIs returns the URIs of all keys in the service.
+func (s *LocalKeyService) KeyURIs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keyURIs := make([]string, 0, len(s.keys))
+	for keyURI := range s.keys {
+		keyURIs = append(keyURIs, keyURI)
+	}
+	return keyURIs
+}
+
+// PublicKey returns the public portion of the key addressed by keyURI.
+func (s *LocalKeyService) PublicKey(_ context.Context, keyURI string) (*SSLibKey, error) {
+	key, err := s.key(keyURI)
+	if err != nil {
+		return nil, err
+	}
+	return key.PublicOnly(), nil
+}
+
+// SignDigest signs a digest with the key addressed by keyURI.
+func (s *LocalKeyService) SignDigest(_ context.Context, keyURI string, hash crypto.Hash, digest []byte) ([]byte, error) {
+	key, err := s.key(keyURI)
+	if err != nil {
+		return nil, err
+	}
+
+	opts, err := signerOptsForScheme(key.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	if opts.HashFunc() != hash {
+		return nil, fmt.Errorf("%w: got %s, want %s for %q", ErrHashMismatch, hash, opts.HashFunc(), key.Scheme)
+	}
+	if hash != 0 && len(digest) != hash.Size() {
+		return nil, fmt.Errorf("%w: digest has %d bytes, want %d", ErrHashMismatch, len(digest), hash.Size())
+	}
+
+	private, err := key.privateCryptoKey()
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, ErrNotPrivateKey
+	}
+
+	return signer.Sign(rand.Reader, digest, opts)
+}
+
+func (s *LocalKeyService) key(keyURI string) (*SSLibKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[keyURI]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrRemoteKeyNotFound, keyURI)
+	}
+	return key, nil
+}
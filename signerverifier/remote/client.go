@@ -0,0 +1,127 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/secure-systems-lab/go-securesystemslib/signerverifier"
+)
+
+// maxResponseSize bounds responses from the server, which only carry a public
+// key or a signature.
+const maxResponseSize = 1 << 20
+
+// errResponseTooLarge indicates that the server sent more than maxResponseSize
+// bytes.
+var errResponseTooLarge = errors.New("remote response too large")
+
+// Client is a signerverifier.RemoteKeyService that talks to a Server.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// ClientOption configures a Client.
+type ClientOption func(*Client)
+
+// WithHTTPClient sets the HTTP client used to talk to the server, e.g. to
+// configure TLS client certificates. http.DefaultClient is used by default.
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// NewClient creates a Client for the server at baseURL.
+func NewClient(baseURL string, opts ...ClientOption) (*Client, error) {
+	if _, err := url.Parse(baseURL); err != nil {
+		return nil, fmt.Errorf("unable to create remote client: %w", err)
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// PublicKey returns the public portion of the key addressed by keyURI.
+func (c *Client) PublicKey(ctx context.Context, keyURI string) (*signerverifier.SSLibKey, error) {
+	key := &signerverifier.SSLibKey{}
+	if err := c.do(ctx, http.MethodGet, c.keyURL(keyURI), nil, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// SignDigest signs a digest with the key addressed by keyURI.
+func (c *Client) SignDigest(ctx context.Context, keyURI string, hash crypto.Hash, digest []byte) ([]byte, error) {
+	hashName, ok := hashNames[hash]
+	if !ok {
+		return nil, fmt.Errorf("%w: unsupported hash %s", signerverifier.ErrHashMismatch, hash)
+	}
+
+	request, err := json.Marshal(&signRequest{Hash: hashName, Digest: digest})
+	if err != nil {
+		return nil, err
+	}
+
+	response := &signResponse{}
+	if err := c.do(ctx, http.MethodPost, c.keyURL(keyURI)+"/sign", request, response); err != nil {
+		return nil, err
+	}
+	return response.Signature, nil
+}
+
+func (c *Client) keyURL(keyURI string) string {
+	return c.baseURL + "/v1/keys/" + url.PathEscape(keyURI)
+}
+
+func (c *Client) do(ctx context.Context, method, url string, body []byte, v any) error {
+	request, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(response.Body, maxResponseSize+1))
+	if err != nil {
+		return err
+	}
+	if len(responseBody) > maxResponseSize {
+		return errResponseTooLarge
+	}
+
+	if response.StatusCode != http.StatusOK {
+		errResponse := &errorResponse{}
+		if err := json.Unmarshal(responseBody, errResponse); err != nil || errResponse.Error == "" {
+			errResponse.Error = response.Status
+		}
+
+		if response.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%w: remote error: %s", signerverifier.ErrRemoteKeyNotFound, errResponse.Error)
+		}
+		return errors.New("remote error: " + errResponse.Error)
+	}
+
+	return json.Unmarshal(responseBody, v)
+}
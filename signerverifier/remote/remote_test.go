@@ -0,0 +1,216 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/secure-systems-lab/go-securesystemslib/signerverifier"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestServer(t *testing.T, opts ...ServerOption) *httptest.Server {
+	t.Helper()
+
+	service := signerverifier.NewLocalKeyService()
+	for keyURI, name := range map[string]string{
+		"ed25519":          "ed25519-test-key",
+		"ecdsa":            "ecdsa-test-key-pem",
+		"team/release/rsa": "rsa-test-key",
+	} {
+		contents, err := os.ReadFile(filepath.Join("..", "test-data", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var key *signerverifier.SSLibKey
+		if strings.HasPrefix(string(contents), "{") {
+			key, err = signerverifier.LoadSSLibKeyStrict(contents)
+		} else {
+			key, err = signerverifier.LoadKey(contents)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := service.AddKey(keyURI, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(NewServer(service, opts...))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := newTestServer(t)
+	client, err := NewClient(server.URL, WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := []byte("test message")
+
+	for _, keyURI := range []string{"ed25519", "ecdsa", "team/release/rsa"} {
+		t.Run(keyURI, func(t *testing.T) {
+			key, err := client.PublicKey(context.Background(), keyURI)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Empty(t, key.KeyVal.Private)
+
+			sv, err := signerverifier.NewRemoteSignerVerifier(context.Background(), client, keyURI)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			es, err := dsse.NewEnvelopeSigner(sv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env, err := es.SignPayload(context.Background(), "application/vnd.dsse+json", message)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Signatures are verified with the public key alone.
+			verifier, err := signerverifier.NewSignerVerifierFromSSLibKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev, err := dsse.NewEnvelopeVerifier(verifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			acceptedKeys, err := ev.Verify(context.Background(), env)
+			assert.Nil(t, err)
+			if assert.Len(t, acceptedKeys, 1) {
+				assert.Equal(t, key.KeyID, acceptedKeys[0].KeyID)
+			}
+		})
+	}
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := client.PublicKey(context.Background(), "unknown")
+		assert.ErrorIs(t, err, signerverifier.ErrRemoteKeyNotFound)
+
+		_, err = client.SignDigest(context.Background(), "unknown", crypto.SHA256, make([]byte, 32))
+		assert.ErrorIs(t, err, signerverifier.ErrRemoteKeyNotFound)
+	})
+
+	t.Run("hash does not match scheme", func(t *testing.T) {
+		_, err := client.SignDigest(context.Background(), "ecdsa", crypto.SHA512, make([]byte, 64))
+		assert.ErrorContains(t, err, signerverifier.ErrHashMismatch.Error())
+	})
+
+	t.Run("unsupported hash", func(t *testing.T) {
+		_, err := client.SignDigest(context.Background(), "ecdsa", crypto.SHA1, make([]byte, 20))
+		assert.ErrorIs(t, err, signerverifier.ErrHashMismatch)
+	})
+
+	t.Run("large message", func(t *testing.T) {
+		// ed25519 signs the full message, which is sent to the server.
+		sv, err := signerverifier.NewRemoteSignerVerifier(context.Background(), client, "ed25519")
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := bytes.Repeat([]byte("a"), 2<<20)
+
+		sig, err := sv.Sign(context.Background(), message)
+		assert.Nil(t, err)
+		assert.Nil(t, sv.Verify(context.Background(), message, sig))
+
+		server := newTestServer(t, WithMaxRequestSize(1<<20))
+		client, err := NewClient(server.URL, WithHTTPClient(server.Client()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sv, err = signerverifier.NewRemoteSignerVerifier(context.Background(), client, "ed25519")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = sv.Sign(context.Background(), message)
+		assert.ErrorContains(t, err, "request body too large")
+	})
+
+	t.Run("response too large", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"keytype":"` + strings.Repeat("a", 2*maxResponseSize)))
+		}))
+		t.Cleanup(server.Close)
+		client, err := NewClient(server.URL, WithHTTPClient(server.Client()))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = client.PublicKey(context.Background(), "ecdsa")
+		assert.ErrorIs(t, err, errResponseTooLarge)
+	})
+}
+
+func TestServer(t *testing.T) {
+	server := newTestServer(t)
+
+	tests := map[string]struct {
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		"public key": {
+			method:         http.MethodGet,
+			path:           "/v1/keys/ecdsa",
+			expectedStatus: http.StatusOK,
+		},
+		"escaped key URI": {
+			method:         http.MethodGet,
+			path:           "/v1/keys/team%2Frelease%2Frsa",
+			expectedStatus: http.StatusOK,
+		},
+		"unknown key": {
+			method:         http.MethodGet,
+			path:           "/v1/keys/unknown",
+			expectedStatus: http.StatusNotFound,
+		},
+		"malformed sign request": {
+			method:         http.MethodPost,
+			path:           "/v1/keys/ecdsa/sign",
+			body:           "not json",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"unsupported hash": {
+			method:         http.MethodPost,
+			path:           "/v1/keys/ecdsa/sign",
+			body:           `{"hash": "md5", "digest": "AAAA"}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		"wrong method": {
+			method:         http.MethodPost,
+			path:           "/v1/keys/ecdsa",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			request, err := http.NewRequest(test.method, server.URL+test.path, strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			response, err := server.Client().Do(request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer response.Body.Close()
+
+			assert.Equal(t, test.expectedStatus, response.StatusCode)
+		})
+	}
+}
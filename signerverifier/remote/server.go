@@ -0,0 +1,147 @@
+/*
+Package remote implements a reference signing server and client for the
+signerverifier.RemoteKeyService abstraction. The server exposes a
+signerverifier.LocalKeyService over HTTP, so that private keys can stay on a
+dedicated signing host while clients create DSSE envelopes with
+signerverifier.RemoteSignerVerifier.
+
+The server does not authenticate clients. It is meant to be deployed behind
+mutually authenticated TLS or an authenticating proxy.
+*/
+package remote
+
+import (
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/secure-systems-lab/go-securesystemslib/signerverifier"
+)
+
+// defaultMaxRequestSize bounds sign requests by default. Requests carry a
+// digest, or for schemes that do not pre-hash, such as ed25519 and ML-DSA, the
+// full message, base64 encoded.
+const defaultMaxRequestSize = 32 << 20
+
+var hashNames = map[crypto.Hash]string{
+	0:             "none",
+	crypto.SHA256: "sha256",
+	crypto.SHA384: "sha384",
+	crypto.SHA512: "sha512",
+}
+
+type signRequest struct {
+	Hash   string `json:"hash"`
+	Digest []byte `json:"digest"`
+}
+
+type signResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Server serves the keys of a RemoteKeyService over HTTP. It provides two
+// endpoints, where the key URI is a single path escaped segment:
+//
+//	GET  /v1/keys/{keyURI}       returns the public key in the securesystemslib JSON format
+//	POST /v1/keys/{keyURI}/sign  signs {"hash": ..., "digest": ...} and returns {"signature": ...}
+type Server struct {
+	service        signerverifier.RemoteKeyService
+	mux            *http.ServeMux
+	maxRequestSize int64
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server)
+
+// WithMaxRequestSize sets the maximum size in bytes of sign requests. As keys
+// whose scheme does not pre-hash receive the full message, it bounds the
+// size of the payloads they can sign. The default is 32 MiB.
+func WithMaxRequestSize(size int64) ServerOption {
+	return func(s *Server) {
+		s.maxRequestSize = size
+	}
+}
+
+// NewServer creates a Server for the keys of service.
+func NewServer(service signerverifier.RemoteKeyService, opts ...ServerOption) *Server {
+	s := &Server{
+		service:        service,
+		mux:            http.NewServeMux(),
+		maxRequestSize: defaultMaxRequestSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.mux.HandleFunc("GET /v1/keys/{keyURI}", s.handlePublicKey)
+	s.mux.HandleFunc("POST /v1/keys/{keyURI}/sign", s.handleSign)
+	return s
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handlePublicKey(w http.ResponseWriter, r *http.Request) {
+	key, err := s.service.PublicKey(r.Context(), r.PathValue("keyURI"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, key.PublicOnly())
+}
+
+func (s *Server) handleSign(w http.ResponseWriter, r *http.Request) {
+	request := &signRequest{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, s.maxRequestSize)).Decode(request); err != nil {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: fmt.Sprintf("invalid request: %s", err)})
+		return
+	}
+
+	hash, err := hashFromName(request.Hash)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, &errorResponse{Error: err.Error()})
+		return
+	}
+
+	sig, err := s.service.SignDigest(r.Context(), r.PathValue("keyURI"), hash, request.Digest)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &signResponse{Signature: sig})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, signerverifier.ErrRemoteKeyNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, signerverifier.ErrHashMismatch):
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, &errorResponse{Error: err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func hashFromName(name string) (crypto.Hash, error) {
+	for hash, hashName := range hashNames {
+		if hashName == name {
+			return hash, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: unsupported hash %q", signerverifier.ErrHashMismatch, name)
+}
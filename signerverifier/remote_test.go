@@ -0,0 +1,180 @@
+package signerverifier
+
+import (
+	"context"
+	"crypto"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteSignerVerifier(t *testing.T) {
+	message := []byte("test message")
+
+	service := NewLocalKeyService()
+	keys := map[string][]byte{
+		"ed25519":        ed25519PrivateKey,
+		"ecdsa":          ecdsaPrivateKey,
+		"ecdsa-p384":     ecdsaP384PrivateKey,
+		"rsa":            rsaPrivateKey,
+		"team/build/rsa": rsaPrivateKeyPKCS8,
+	}
+	for keyURI, keyBytes := range keys {
+		key, err := LoadKey(keyBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := service.AddKey(keyURI, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for keyURI, keyBytes := range keys {
+		t.Run(keyURI, func(t *testing.T) {
+			key, err := LoadKey(keyBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			localVerifier, err := NewSignerVerifierFromSSLibKey(key.PublicOnly())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sv, err := NewRemoteSignerVerifier(context.Background(), service, keyURI)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			keyID, err := sv.KeyID()
+			assert.Nil(t, err)
+			assert.Equal(t, key.KeyID, keyID)
+			assert.True(t, sv.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(localVerifier.Public()))
+
+			sig, err := sv.Sign(context.Background(), message)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Nil(t, localVerifier.Verify(context.Background(), message, sig))
+			assert.Nil(t, sv.Verify(context.Background(), message, sig))
+			assert.ErrorIs(t, sv.Verify(context.Background(), []byte("another message"), sig), ErrSignatureVerificationFailed)
+		})
+	}
+
+	t.Run("with DSSE envelope", func(t *testing.T) {
+		sv, err := NewRemoteSignerVerifier(context.Background(), service, "ecdsa")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		es, err := dsse.NewEnvelopeSigner(sv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, err := es.SignPayload(context.Background(), "application/vnd.dsse+json", message)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ev, err := dsse.NewEnvelopeVerifier(sv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ev.Verify(context.Background(), env)
+		assert.Nil(t, err)
+	})
+
+	t.Run("public key only", func(t *testing.T) {
+		key, err := service.PublicKey(context.Background(), "rsa")
+		assert.Nil(t, err)
+		assert.Empty(t, key.KeyVal.Private)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		_, err := NewRemoteSignerVerifier(context.Background(), service, "unknown")
+		assert.ErrorIs(t, err, ErrRemoteKeyNotFound)
+
+		_, err = service.SignDigest(context.Background(), "unknown", crypto.SHA256, make([]byte, 32))
+		assert.ErrorIs(t, err, ErrRemoteKeyNotFound)
+	})
+
+	t.Run("hash does not match scheme", func(t *testing.T) {
+		_, err := service.SignDigest(context.Background(), "ecdsa", crypto.SHA512, make([]byte, 64))
+		assert.ErrorIs(t, err, ErrHashMismatch)
+
+		_, err = service.SignDigest(context.Background(), "ecdsa", crypto.SHA256, make([]byte, 20))
+		assert.ErrorIs(t, err, ErrHashMismatch)
+
+		_, err = service.SignDigest(context.Background(), "ed25519", crypto.SHA256, make([]byte, 32))
+		assert.ErrorIs(t, err, ErrHashMismatch)
+	})
+
+	t.Run("keyid does not match key", func(t *testing.T) {
+		service := NewLocalKeyService()
+		key, err := LoadKey(ecdsaPrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		key.KeyID = strings.Repeat("0", 64)
+		if err := service.AddKey("ecdsa", key); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewRemoteSignerVerifier(context.Background(), service, "ecdsa")
+		assert.ErrorIs(t, err, ErrKeyIDMismatch)
+	})
+
+	t.Run("public key cannot be added", func(t *testing.T) {
+		key, err := LoadKey(ecdsaPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.ErrorIs(t, service.AddKey("public", key), ErrNotPrivateKey)
+	})
+}
+
+func TestLoadLocalKeyService(t *testing.T) {
+	dir := t.TempDir()
+	for name, source := range map[string]string{
+		"ed25519": "ed25519-test-key",
+		"ecdsa":   "ecdsa-test-key-pem",
+		"rsa":     "rsa-test-key",
+	} {
+		contents, err := os.ReadFile(filepath.Join("test-data", source))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), contents, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".hidden"), []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	service, err := LoadLocalKeyService(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyURIs := service.KeyURIs()
+	slices.Sort(keyURIs)
+	assert.Equal(t, []string{"ecdsa", "ed25519", "rsa"}, keyURIs)
+
+	key, err := service.PublicKey(context.Background(), "ed25519")
+	assert.Nil(t, err)
+	assert.Equal(t, "52e3b8e73279d6ebdd62a5016e2725ff284f569665eb92ccb145d83817a02997", key.KeyID)
+
+	t.Run("public keys are rejected", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir, "public"), ecdsaPublicKey, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := LoadLocalKeyService(dir)
+		assert.ErrorIs(t, err, ErrNotPrivateKey)
+	})
+}
@@ -5,75 +5,176 @@ import (
 	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/sha256"
+	_ "crypto/sha256" // register hash functions used by rsaSchemes
+	_ "crypto/sha512"
 	"crypto/x509"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 )
 
 const (
-	RSAKeyType       = "rsa"
-	RSAKeyScheme     = "rsassa-pss-sha256"
-	RSAPrivateKeyPEM = "RSA PRIVATE KEY"
+	RSAKeyType                 = "rsa"
+	RSAKeyScheme               = "rsassa-pss-sha256"
+	RSAPSSSHA384KeyScheme      = "rsassa-pss-sha384"
+	RSAPSSSHA512KeyScheme      = "rsassa-pss-sha512"
+	RSAPKCS1v15SHA256KeyScheme = "rsa-pkcs1v15-sha256"
+	RSAPKCS1v15SHA384KeyScheme = "rsa-pkcs1v15-sha384"
+	RSAPKCS1v15SHA512KeyScheme = "rsa-pkcs1v15-sha512"
+	RSAPrivateKeyPEM           = "RSA PRIVATE KEY"
 )
 
-// RSAPSSSignerVerifier is a dsse.SignerVerifier compliant interface to sign and
-// verify signatures using RSA keys following the RSA-PSS scheme.
-type RSAPSSSignerVerifier struct {
+// rsaScheme captures the hash algorithm and padding mandated by an RSA scheme.
+type rsaScheme struct {
+	hash crypto.Hash
+	pss  bool
+}
+
+var rsaSchemes = map[string]rsaScheme{
+	RSAKeyScheme:               {hash: crypto.SHA256, pss: true},
+	RSAPSSSHA384KeyScheme:      {hash: crypto.SHA384, pss: true},
+	RSAPSSSHA512KeyScheme:      {hash: crypto.SHA512, pss: true},
+	RSAPKCS1v15SHA256KeyScheme: {hash: crypto.SHA256, pss: false},
+	RSAPKCS1v15SHA384KeyScheme: {hash: crypto.SHA384, pss: false},
+	RSAPKCS1v15SHA512KeyScheme: {hash: crypto.SHA512, pss: false},
+}
+
+// RSASignerVerifier is a dsse.SignerVerifier compliant interface to sign and
+// verify signatures using RSA keys. It follows whichever RSA-PSS or RSA
+// PKCS#1 v1.5 scheme the key was created with.
+type RSASignerVerifier struct {
 	keyID   string
+	scheme  rsaScheme
 	private *rsa.PrivateKey
 	public  *rsa.PublicKey
 }
 
-// NewRSAPSSSignerVerifierFromSSLibKey creates an RSAPSSSignerVerifier from an
-// SSLibKey.
-func NewRSAPSSSignerVerifierFromSSLibKey(key *SSLibKey) (*RSAPSSSignerVerifier, error) {
+// RSAPSSSignerVerifier is the former name of RSASignerVerifier, from when only
+// RSA-PSS schemes were supported.
+//
+// Deprecated: use RSASignerVerifier, which also handles RSA PKCS#1 v1.5
+// schemes.
+type RSAPSSSignerVerifier = RSASignerVerifier
+
+// NewRSASignerVerifierFromSSLibKey creates an RSASignerVerifier from an
+// SSLibKey. The key's scheme selects the hash algorithm and padding, and an
+// error is returned for schemes that are not supported.
+func NewRSASignerVerifierFromSSLibKey(key *SSLibKey) (*RSASignerVerifier, error) {
 	if len(key.KeyVal.Public) == 0 {
 		return nil, ErrInvalidKey
 	}
 
+	scheme, ok := rsaSchemes[key.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unable to create RSA signerverifier: %w: %q", ErrUnknownScheme, key.Scheme)
+	}
+
 	_, publicParsedKey, err := decodeAndParsePEM([]byte(key.KeyVal.Public))
 	if err != nil {
-		return nil, fmt.Errorf("unable to create RSA-PSS signerverifier: %w", err)
+		return nil, fmt.Errorf("unable to create RSA signerverifier: %w", err)
+	}
+
+	public, ok := publicParsedKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unable to create RSA signerverifier: %w", ErrUnknownKeyType)
+	}
+
+	sv := &RSASignerVerifier{
+		keyID:   key.KeyID,
+		scheme:  scheme,
+		public:  public,
+		private: nil,
 	}
 
 	if len(key.KeyVal.Private) > 0 {
 		_, privateParsedKey, err := decodeAndParsePEM([]byte(key.KeyVal.Private))
 		if err != nil {
-			return nil, fmt.Errorf("unable to create RSA-PSS signerverifier: %w", err)
+			return nil, fmt.Errorf("unable to create RSA signerverifier: %w", err)
 		}
 
-		return &RSAPSSSignerVerifier{
-			keyID:   key.KeyID,
-			public:  publicParsedKey.(*rsa.PublicKey),
-			private: privateParsedKey.(*rsa.PrivateKey),
-		}, nil
+		private, ok := privateParsedKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unable to create RSA signerverifier: %w", ErrUnknownKeyType)
+		}
+		sv.private = private
 	}
 
-	return &RSAPSSSignerVerifier{
-		keyID:   key.KeyID,
-		public:  publicParsedKey.(*rsa.PublicKey),
-		private: nil,
-	}, nil
+	return sv, nil
+}
+
+// NewRSAPSSSignerVerifierFromSSLibKey creates an RSASignerVerifier from an
+// SSLibKey.
+//
+// Deprecated: use NewRSASignerVerifierFromSSLibKey.
+func NewRSAPSSSignerVerifierFromSSLibKey(key *SSLibKey) (*RSASignerVerifier, error) {
+	return NewRSASignerVerifierFromSSLibKey(key)
+}
+
+// GenerateRSAPSSKey creates a new RSA key of the given size in bits and returns
+// it as an SSLibKey with both the public and private portions populated.
+func GenerateRSAPSSKey(bits int) (*SSLibKey, error) {
+	private, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate RSA key: %w", err)
+	}
+
+	return newSSLibKeyFromPrivateKey(private)
 }
 
 // Sign creates a signature for `data`.
-func (sv *RSAPSSSignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
+func (sv *RSASignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
 	if sv.private == nil {
 		return nil, ErrNotPrivateKey
 	}
 
-	hashedData := hashBeforeSigning(data, sha256.New())
+	return sv.signDigest(hashBeforeSigning(data, sv.scheme.hash.New()))
+}
+
+// SignStream creates a signature for the data read from `r`, hashing it
+// incrementally. It implements dsse.StreamSigner.
+func (sv *RSASignerVerifier) SignStream(_ context.Context, r io.Reader) ([]byte, error) {
+	if sv.private == nil {
+		return nil, ErrNotPrivateKey
+	}
 
-	return rsa.SignPSS(rand.Reader, sv.private, crypto.SHA256, hashedData, &rsa.PSSOptions{SaltLength: sha256.Size, Hash: crypto.SHA256})
+	hashedData, err := hashReaderBeforeSigning(r, sv.scheme.hash.New())
+	if err != nil {
+		return nil, err
+	}
+	return sv.signDigest(hashedData)
+}
+
+func (sv *RSASignerVerifier) signDigest(hashedData []byte) ([]byte, error) {
+	if sv.scheme.pss {
+		return rsa.SignPSS(rand.Reader, sv.private, sv.scheme.hash, hashedData, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: sv.scheme.hash})
+	}
+	return rsa.SignPKCS1v15(rand.Reader, sv.private, sv.scheme.hash, hashedData)
 }
 
 // Verify verifies the `sig` value passed in against `data`.
-func (sv *RSAPSSSignerVerifier) Verify(_ context.Context, data []byte, sig []byte) error {
-	hashedData := hashBeforeSigning(data, sha256.New())
+func (sv *RSASignerVerifier) Verify(_ context.Context, data []byte, sig []byte) error {
+	return sv.verifyDigest(hashBeforeSigning(data, sv.scheme.hash.New()), sig)
+}
 
-	if err := rsa.VerifyPSS(sv.public, crypto.SHA256, hashedData, sig, &rsa.PSSOptions{SaltLength: sha256.Size, Hash: crypto.SHA256}); err != nil {
+// VerifyStream verifies the `sig` value passed in against the data read from
+// `r`, hashing it incrementally. It implements dsse.StreamVerifier.
+func (sv *RSASignerVerifier) VerifyStream(_ context.Context, r io.Reader, sig []byte) error {
+	hashedData, err := hashReaderBeforeSigning(r, sv.scheme.hash.New())
+	if err != nil {
+		return err
+	}
+	return sv.verifyDigest(hashedData, sig)
+}
+
+func (sv *RSASignerVerifier) verifyDigest(hashedData, sig []byte) error {
+	var err error
+	if sv.scheme.pss {
+		err = rsa.VerifyPSS(sv.public, sv.scheme.hash, hashedData, sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: sv.scheme.hash})
+	} else {
+		err = rsa.VerifyPKCS1v15(sv.public, sv.scheme.hash, hashedData, sig)
+	}
+	if err != nil {
 		return ErrSignatureVerificationFailed
 	}
 
@@ -81,14 +182,14 @@ func (sv *RSAPSSSignerVerifier) Verify(_ context.Context, data []byte, sig []byt
 }
 
 // KeyID returns the identifier of the key used to create the
-// RSAPSSSignerVerifier instance.
-func (sv *RSAPSSSignerVerifier) KeyID() (string, error) {
+// RSASignerVerifier instance.
+func (sv *RSASignerVerifier) KeyID() (string, error) {
 	return sv.keyID, nil
 }
 
 // Public returns the public portion of the key used to create the
-// RSAPSSSignerVerifier instance.
-func (sv *RSAPSSSignerVerifier) Public() crypto.PublicKey {
+// RSASignerVerifier instance.
+func (sv *RSASignerVerifier) Public() crypto.PublicKey {
 	return sv.public
 }
 
@@ -1,13 +1,18 @@
 package signerverifier
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/hex"
+	"encoding/pem"
 	"errors"
+	"fmt"
 	"strings"
+
+	"github.com/secure-systems-lab/go-securesystemslib/cjson"
 )
 
 var KeyIDHashAlgorithms = []string{"sha256", "sha512"}
@@ -16,6 +21,8 @@ var (
 	ErrNotPrivateKey               = errors.New("loaded key is not a private key")
 	ErrSignatureVerificationFailed = errors.New("failed to verify signature")
 	ErrUnknownKeyType              = errors.New("unknown key type")
+	ErrUnknownScheme               = errors.New("unknown key scheme")
+	ErrSchemeMismatch              = errors.New("key scheme does not match key")
 	ErrInvalidThreshold            = errors.New("threshold is either less than 1 or greater than number of provided public keys")
 	ErrInvalidKey                  = errors.New("key object has no value")
 	ErrInvalidPEM                  = errors.New("unable to parse PEM block")
@@ -43,8 +50,19 @@ type KeyVal struct {
 }
 
 // LoadKey returns an SSLibKey object when provided a PEM encoded key.
-// Currently, RSA, ED25519, and ECDSA keys are supported.
+// Currently, RSA, ED25519, and ECDSA keys are supported. The scheme of ECDSA
+// keys is derived from their curve (NIST P-256, P-384, or P-521). Unencrypted
+// OpenSSH private keys and authorized_keys style public keys are also
+// accepted, see LoadSSHKey. Encrypted keys must be loaded with
+// LoadKeyWithPassphrase.
 func LoadKey(keyBytes []byte) (*SSLibKey, error) {
+	if isSSHKey(keyBytes) {
+		return LoadSSHKey(keyBytes, nil)
+	}
+	if block, _ := pem.Decode(keyBytes); block != nil && block.Type == EncryptedPrivateKeyPEM {
+		return nil, ErrPassphraseRequired
+	}
+
 	pemBlock, rawKey, err := decodeAndParsePEM(keyBytes)
 	if err != nil {
 		return nil, err
@@ -108,13 +126,17 @@ func LoadKey(keyBytes []byte) (*SSLibKey, error) {
 		if err != nil {
 			return nil, err
 		}
+		scheme, err := ecdsaSchemeForCurve(k.Curve)
+		if err != nil {
+			return nil, err
+		}
 		key = &SSLibKey{
 			KeyIDHashAlgorithms: KeyIDHashAlgorithms,
 			KeyType:             ECDSAKeyType,
 			KeyVal: KeyVal{
 				Public: strings.TrimSpace(string(generatePEMBlock(pubKeyBytes, PublicKeyPEM))),
 			},
-			Scheme: ECDSAKeyScheme,
+			Scheme: scheme,
 		}
 
 	case *ecdsa.PrivateKey:
@@ -122,6 +144,10 @@ func LoadKey(keyBytes []byte) (*SSLibKey, error) {
 		if err != nil {
 			return nil, err
 		}
+		scheme, err := ecdsaSchemeForCurve(k.Curve)
+		if err != nil {
+			return nil, err
+		}
 		key = &SSLibKey{
 			KeyIDHashAlgorithms: KeyIDHashAlgorithms,
 			KeyType:             ECDSAKeyType,
@@ -129,11 +155,14 @@ func LoadKey(keyBytes []byte) (*SSLibKey, error) {
 				Public:  strings.TrimSpace(string(generatePEMBlock(pubKeyBytes, PublicKeyPEM))),
 				Private: strings.TrimSpace(string(generatePEMBlock(pemBlock.Bytes, PrivateKeyPEM))),
 			},
-			Scheme: ECDSAKeyScheme,
+			Scheme: scheme,
 		}
 
 	default:
-		return nil, ErrUnknownKeyType
+		key, err = newSSLibKeyFromMLDSAKey(pemBlock, rawKey)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	keyID, err := calculateKeyID(key)
@@ -144,3 +173,127 @@ func LoadKey(keyBytes []byte) (*SSLibKey, error) {
 
 	return key, nil
 }
+
+// MarshalPublicKeyPEM returns the public portion of the key as a PEM encoded
+// PKIX block, regardless of how it is serialized in the SSLibKey.
+func (k *SSLibKey) MarshalPublicKeyPEM() ([]byte, error) {
+	public, err := k.publicCryptoKey()
+	if err != nil {
+		return nil, err
+	}
+
+	pubKeyBytes, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	return generatePEMBlock(pubKeyBytes, PublicKeyPEM), nil
+}
+
+// MarshalPrivateKeyPEM returns the private portion of the key as a PEM
+// encoded PKCS8 block, regardless of how it is serialized in the SSLibKey.
+func (k *SSLibKey) MarshalPrivateKeyPEM() ([]byte, error) {
+	private, err := k.privateCryptoKey()
+	if err != nil {
+		return nil, err
+	}
+
+	privKeyBytes, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return generatePEMBlock(privKeyBytes, PrivateKeyPEM), nil
+}
+
+// PublicOnly returns a copy of the key without its private portion, which is
+// safe to publish.
+func (k *SSLibKey) PublicOnly() *SSLibKey {
+	public := *k
+	public.KeyIDHashAlgorithms = append([]string(nil), k.KeyIDHashAlgorithms...)
+	public.KeyVal.Private = ""
+	return &public
+}
+
+// MarshalCanonical returns the canonical JSON encoding of the public portion
+// of the key, in the shape python-securesystemslib and TUF use for keys in
+// metadata: "keytype", "scheme", "keyval" and, if set,
+// "keyid_hash_algorithms". The keyid is not included, as metadata lists keys
+// by keyid. The private portion is never included. The keyid is computed over
+// this encoding.
+func (k *SSLibKey) MarshalCanonical() ([]byte, error) {
+	if len(k.publicKeyVal()) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	return cjson.EncodeCanonical(k.publicMetadata())
+}
+
+// publicCryptoKey parses the public portion of the key.
+func (k *SSLibKey) publicCryptoKey() (crypto.PublicKey, error) {
+	if len(k.KeyVal.Public) == 0 {
+		return nil, ErrInvalidKey
+	}
+
+	if k.KeyType == ED25519KeyType {
+		publicBytes, err := hex.DecodeString(k.KeyVal.Public)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode ED25519 public key: %w", err)
+		}
+		return ed25519.PublicKey(publicBytes), nil
+	}
+
+	_, parsedKey, err := decodeAndParsePEM([]byte(k.KeyVal.Public))
+	if err != nil {
+		return nil, err
+	}
+	return parsedKey, nil
+}
+
+// privateCryptoKey parses the private portion of the key.
+func (k *SSLibKey) privateCryptoKey() (crypto.PrivateKey, error) {
+	if len(k.KeyVal.Private) == 0 {
+		return nil, ErrNotPrivateKey
+	}
+
+	if k.KeyType == ED25519KeyType {
+		privateBytes, err := hex.DecodeString(k.KeyVal.Private)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode ED25519 private key: %w", err)
+		}
+		switch len(privateBytes) {
+		case ed25519.SeedSize:
+			return ed25519.NewKeyFromSeed(privateBytes), nil
+		case ed25519.PrivateKeySize:
+			return ed25519.PrivateKey(privateBytes), nil
+		default:
+			return nil, ErrInvalidKey
+		}
+	}
+
+	_, parsedKey, err := decodeAndParsePEM([]byte(k.KeyVal.Private))
+	if err != nil {
+		return nil, err
+	}
+	return parsedKey, nil
+}
+
+// newSSLibKeyFromPrivateKey serializes a private key as PKCS8 and loads it, so
+// generated keys and keys parsed from other formats are populated exactly like
+// PEM keys read with LoadKey.
+func newSSLibKeyFromPrivateKey(private crypto.PrivateKey) (*SSLibKey, error) {
+	privKeyBytes, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+	return LoadKey(generatePEMBlock(privKeyBytes, PrivateKeyPEM))
+}
+
+// newSSLibKeyFromPublicKey serializes a public key as PKIX and loads it, so
+// keys parsed from other formats are populated exactly like PEM keys read with
+// LoadKey.
+func newSSLibKeyFromPublicKey(public crypto.PublicKey) (*SSLibKey, error) {
+	pubKeyBytes, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+	return LoadKey(generatePEMBlock(pubKeyBytes, PublicKeyPEM))
+}
@@ -1,8 +1,11 @@
 package signerverifier
 
 import (
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -30,6 +33,18 @@ var ecdsaPrivateKey []byte
 //go:embed test-data/ecdsa-test-key-pem.pub
 var ecdsaPublicKey []byte
 
+//go:embed test-data/ecdsa-p384-test-key-pem
+var ecdsaP384PrivateKey []byte
+
+//go:embed test-data/ecdsa-p384-test-key-pem.pub
+var ecdsaP384PublicKey []byte
+
+//go:embed test-data/ecdsa-p521-test-key-pem
+var ecdsaP521PrivateKey []byte
+
+//go:embed test-data/ecdsa-p521-test-key-pem.pub
+var ecdsaP521PublicKey []byte
+
 func TestLoadKey(t *testing.T) {
 	// RSA expected values
 	expectedRSAPrivateKey := strings.TrimSpace(strings.ReplaceAll(string(rsaPrivateKey), "\r\n", "\n"))
@@ -46,6 +61,10 @@ func TestLoadKey(t *testing.T) {
 	expectedECDSAPrivateKey := strings.TrimSpace(strings.ReplaceAll(string(ecdsaPrivateKey), "\r\n", "\n"))
 	expectedECDSAPublicKey := strings.TrimSpace(strings.ReplaceAll(string(ecdsaPublicKey), "\r\n", "\n"))
 	expectedECDSAKeyID := "98adf38602c48c5479e9a991ee3f8cbf541ee4f985e00f7a5fc4148d9a45b704"
+	expectedECDSAP384PublicKey := strings.TrimSpace(strings.ReplaceAll(string(ecdsaP384PublicKey), "\r\n", "\n"))
+	expectedECDSAP384KeyID := "b30cd17fcd83c81b2fdd2924d6a68b5325e39bd5c4de7574f6cffcadfdcd87fb"
+	expectedECDSAP521PublicKey := strings.TrimSpace(strings.ReplaceAll(string(ecdsaP521PublicKey), "\r\n", "\n"))
+	expectedECDSAP521KeyID := "b581dd11216a573de3f01d5b5e9cbdcedfe775319933a02dd9036b2b3446830f"
 
 	tests := map[string]struct {
 		keyBytes           []byte
@@ -111,6 +130,22 @@ func TestLoadKey(t *testing.T) {
 			expectedKeyType:    ECDSAKeyType,
 			expectedScheme:     ECDSAKeyScheme,
 		},
+		"ECDSA P-384 public key": {
+			keyBytes:           ecdsaP384PublicKey,
+			expectedPrivateKey: "",
+			expectedPublicKey:  expectedECDSAP384PublicKey,
+			expectedKeyID:      expectedECDSAP384KeyID,
+			expectedKeyType:    ECDSAKeyType,
+			expectedScheme:     ECDSAP384KeyScheme,
+		},
+		"ECDSA P-521 public key": {
+			keyBytes:           ecdsaP521PublicKey,
+			expectedPrivateKey: "",
+			expectedPublicKey:  expectedECDSAP521PublicKey,
+			expectedKeyID:      expectedECDSAP521KeyID,
+			expectedKeyType:    ECDSAKeyType,
+			expectedScheme:     ECDSAP521KeyScheme,
+		},
 	}
 
 	for name, test := range tests {
@@ -125,3 +160,139 @@ func TestLoadKey(t *testing.T) {
 		})
 	}
 }
+
+func TestMarshalPEM(t *testing.T) {
+	tests := map[string]struct {
+		privateKeyBytes []byte
+		publicKeyBytes  []byte
+	}{
+		"RSA key": {
+			privateKeyBytes: rsaPrivateKeyPKCS8,
+			publicKeyBytes:  rsaPublicKey,
+		},
+		"ED25519 key": {
+			privateKeyBytes: ed25519PrivateKey,
+			publicKeyBytes:  ed25519PublicKey,
+		},
+		"ECDSA key": {
+			privateKeyBytes: ecdsaPrivateKey,
+			publicKeyBytes:  ecdsaPublicKey,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, err := LoadKey(test.privateKeyBytes)
+			assert.Nil(t, err)
+
+			privatePEM, err := key.MarshalPrivateKeyPEM()
+			assert.Nil(t, err)
+			assert.Equal(t, strings.TrimSpace(strings.ReplaceAll(string(test.privateKeyBytes), "\r\n", "\n")), strings.TrimSpace(string(privatePEM)))
+
+			publicPEM, err := key.MarshalPublicKeyPEM()
+			assert.Nil(t, err)
+			assert.Equal(t, strings.TrimSpace(strings.ReplaceAll(string(test.publicKeyBytes), "\r\n", "\n")), strings.TrimSpace(string(publicPEM)))
+
+			publicKey, err := LoadKey(publicPEM)
+			assert.Nil(t, err)
+			assert.Equal(t, key.KeyID, publicKey.KeyID)
+
+			_, err = publicKey.MarshalPrivateKeyPEM()
+			assert.ErrorIs(t, err, ErrNotPrivateKey)
+		})
+	}
+
+	t.Run("ED25519 key in custom securesystemslib format", func(t *testing.T) {
+		key, err := LoadED25519KeyFromFile(filepath.Join("test-data", "ed25519-test-key"))
+		assert.Nil(t, err)
+
+		privatePEM, err := key.MarshalPrivateKeyPEM()
+		assert.Nil(t, err)
+		assert.Equal(t, strings.TrimSpace(string(ed25519PrivateKey)), strings.TrimSpace(string(privatePEM)))
+	})
+}
+
+func TestPublicOnly(t *testing.T) {
+	key, err := LoadKey(ecdsaPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public := key.PublicOnly()
+	assert.Empty(t, public.KeyVal.Private)
+	assert.Equal(t, key.KeyVal.Public, public.KeyVal.Public)
+	assert.Equal(t, key.KeyID, public.KeyID)
+
+	// The original key is left untouched.
+	assert.NotEmpty(t, key.KeyVal.Private)
+	public.KeyIDHashAlgorithms[0] = "sha512"
+	assert.Equal(t, "sha256", key.KeyIDHashAlgorithms[0])
+}
+
+func TestMarshalCanonical(t *testing.T) {
+	key, err := LoadKey(ed25519PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	canonical, err := key.MarshalCanonical()
+	assert.Nil(t, err)
+	assert.Equal(t, `{"keyid_hash_algorithms":["sha256","sha512"],"keytype":"ed25519","keyval":{"public":"3f586ce67329419fb0081bd995914e866a7205da463d593b3b490eab2b27fd3f"},"scheme":"ed25519"}`, string(canonical))
+
+	// The canonical encoding is what the keyid is computed over.
+	digest := sha256.Sum256(canonical)
+	assert.Equal(t, key.KeyID, hex.EncodeToString(digest[:]))
+
+	t.Run("PEM public key", func(t *testing.T) {
+		key, err := LoadKey(ecdsaPrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		canonical, err := key.MarshalCanonical()
+		assert.Nil(t, err)
+		assert.NotContains(t, string(canonical), "PRIVATE KEY")
+		// Like python-securesystemslib, canonical JSON only escapes
+		// backslashes and quotes, so newlines are kept as is.
+		assert.Contains(t, string(canonical), "\"public\":\"-----BEGIN PUBLIC KEY-----\nMFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAE")
+	})
+
+	t.Run("without keyid hash algorithms", func(t *testing.T) {
+		key := key.PublicOnly()
+		key.KeyIDHashAlgorithms = nil
+
+		canonical, err := key.MarshalCanonical()
+		assert.Nil(t, err)
+		assert.Equal(t, `{"keytype":"ed25519","keyval":{"public":"3f586ce67329419fb0081bd995914e866a7205da463d593b3b490eab2b27fd3f"},"scheme":"ed25519"}`, string(canonical))
+
+		keyID, err := calculateKeyID(key)
+		assert.Nil(t, err)
+		digest := sha256.Sum256(canonical)
+		assert.Equal(t, keyID, hex.EncodeToString(digest[:]))
+	})
+
+	t.Run("identity key", func(t *testing.T) {
+		key := &SSLibKey{
+			KeyType: "sigstore-oidc",
+			Scheme:  "Fulcio",
+			KeyVal: KeyVal{
+				Identity: "user@example.com",
+				Issuer:   "https://accounts.example.com",
+			},
+		}
+
+		canonical, err := key.MarshalCanonical()
+		assert.Nil(t, err)
+		assert.Equal(t, `{"keytype":"sigstore-oidc","keyval":{"identity":"user@example.com","issuer":"https://accounts.example.com"},"scheme":"Fulcio"}`, string(canonical))
+
+		keyID, err := calculateKeyID(key)
+		assert.Nil(t, err)
+		digest := sha256.Sum256(canonical)
+		assert.Equal(t, keyID, hex.EncodeToString(digest[:]))
+	})
+
+	t.Run("empty key", func(t *testing.T) {
+		_, err := (&SSLibKey{KeyType: ED25519KeyType}).MarshalCanonical()
+		assert.ErrorIs(t, err, ErrInvalidKey)
+	})
+}
@@ -0,0 +1,387 @@
+package signerverifier
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/secure-systems-lab/go-securesystemslib/cjson"
+)
+
+const (
+	SigstoreKeyType   = "sigstore-oidc"
+	SigstoreKeyScheme = "Fulcio"
+)
+
+var (
+	ErrInvalidSigstoreBundle             = errors.New("invalid sigstore bundle")
+	ErrTransparencyLogVerificationFailed = errors.New("unable to verify transparency log entry")
+)
+
+// SigstoreTrustedRoot holds the trust material used to verify Sigstore bundles
+// offline. It is usually distributed out of band, e.g. via the Sigstore TUF
+// repository.
+type SigstoreTrustedRoot struct {
+	// FulcioRoots are the trusted Fulcio root CA certificates.
+	FulcioRoots []*x509.Certificate
+	// FulcioIntermediates are Fulcio intermediate CA certificates used in
+	// addition to those included in bundles.
+	FulcioIntermediates []*x509.Certificate
+	// RekorPublicKeys are the public keys of the trusted Rekor transparency
+	// logs. Logs are identified by the SHA-256 digest of their PKIX encoded
+	// key.
+	RekorPublicKeys []crypto.PublicKey
+	// CTLogPublicKeys are the public keys of the trusted certificate
+	// transparency logs. Each certificate must include a signed certificate
+	// timestamp from one of them.
+	CTLogPublicKeys []crypto.PublicKey
+}
+
+// SigstoreVerifier is a dsse.Verifier compliant interface to verify signatures
+// created with Sigstore's keyless flow, which python-securesystemslib models
+// as keys of type "sigstore-oidc". Such keys only record the identity and OIDC
+// issuer of the signer. A signature is a Sigstore bundle, which carries the
+// short-lived Fulcio certificate and the Rekor transparency log entry for the
+// signature. Bundles are verified offline against a SigstoreTrustedRoot.
+type SigstoreVerifier struct {
+	keyID       string
+	identity    string
+	issuer      string
+	roots       *x509.CertPool
+	issuers     []*x509.Certificate
+	certOptions []CertificateVerifierOption
+	rekorKeys   map[string]crypto.PublicKey
+	ctLogKeys   map[string]crypto.PublicKey
+}
+
+// sigstoreBundle is the JSON encoding of a Sigstore bundle, see
+// https://github.com/sigstore/protobuf-specs. Only bundles with a message
+// signature over the artifact are supported.
+type sigstoreBundle struct {
+	MediaType            string                       `json:"mediaType"`
+	VerificationMaterial sigstoreVerificationMaterial `json:"verificationMaterial"`
+	MessageSignature     *sigstoreMessageSignature    `json:"messageSignature,omitempty"`
+}
+
+type sigstoreVerificationMaterial struct {
+	Certificate          *sigstoreCertificate          `json:"certificate,omitempty"`
+	X509CertificateChain *sigstoreX509CertificateChain `json:"x509CertificateChain,omitempty"`
+	TlogEntries          []sigstoreTlogEntry           `json:"tlogEntries"`
+}
+
+type sigstoreCertificate struct {
+	RawBytes []byte `json:"rawBytes"`
+}
+
+type sigstoreX509CertificateChain struct {
+	Certificates []sigstoreCertificate `json:"certificates"`
+}
+
+type sigstoreMessageSignature struct {
+	MessageDigest *sigstoreMessageDigest `json:"messageDigest,omitempty"`
+	Signature     []byte                 `json:"signature"`
+}
+
+type sigstoreMessageDigest struct {
+	Algorithm string `json:"algorithm"`
+	Digest    []byte `json:"digest"`
+}
+
+type sigstoreTlogEntry struct {
+	LogIndex          int64                     `json:"logIndex,string"`
+	LogID             sigstoreLogID             `json:"logId"`
+	KindVersion       sigstoreKindVersion       `json:"kindVersion"`
+	IntegratedTime    int64                     `json:"integratedTime,string"`
+	InclusionPromise  *sigstoreInclusionPromise `json:"inclusionPromise,omitempty"`
+	InclusionProof    *sigstoreInclusionProof   `json:"inclusionProof,omitempty"`
+	CanonicalizedBody []byte                    `json:"canonicalizedBody"`
+}
+
+type sigstoreLogID struct {
+	KeyID []byte `json:"keyId"`
+}
+
+type sigstoreKindVersion struct {
+	Kind    string `json:"kind"`
+	Version string `json:"version"`
+}
+
+type sigstoreInclusionPromise struct {
+	SignedEntryTimestamp []byte `json:"signedEntryTimestamp"`
+}
+
+type sigstoreInclusionProof struct {
+	LogIndex   int64              `json:"logIndex,string"`
+	RootHash   []byte             `json:"rootHash"`
+	TreeSize   int64              `json:"treeSize,string"`
+	Hashes     [][]byte           `json:"hashes"`
+	Checkpoint sigstoreCheckpoint `json:"checkpoint"`
+}
+
+type sigstoreCheckpoint struct {
+	Envelope string `json:"envelope"`
+}
+
+// hashedRekord is the body of a Rekor "hashedrekord" entry, version 0.0.1.
+type hashedRekord struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Spec       struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content   []byte `json:"content"`
+			PublicKey struct {
+				Content []byte `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// NewSigstoreVerifierFromSSLibKey creates a SigstoreVerifier from an SSLibKey
+// of type "sigstore-oidc". KeyVal.Identity and KeyVal.Issuer must be set; the
+// certificate in each bundle must have been issued to that identity by that
+// OIDC issuer.
+func NewSigstoreVerifierFromSSLibKey(key *SSLibKey, trustedRoot *SigstoreTrustedRoot) (*SigstoreVerifier, error) {
+	if key == nil || len(key.KeyVal.Identity) == 0 || len(key.KeyVal.Issuer) == 0 {
+		return nil, ErrInvalidKey
+	}
+	if key.KeyType != SigstoreKeyType || key.Scheme != SigstoreKeyScheme {
+		return nil, fmt.Errorf("unable to create sigstore verifier: %w: keytype %q with scheme %q", ErrUnknownKeyType, key.KeyType, key.Scheme)
+	}
+	if trustedRoot == nil || len(trustedRoot.FulcioRoots) == 0 || len(trustedRoot.RekorPublicKeys) == 0 || len(trustedRoot.CTLogPublicKeys) == 0 {
+		return nil, fmt.Errorf("unable to create sigstore verifier: incomplete trusted root")
+	}
+
+	roots := x509.NewCertPool()
+	for _, root := range trustedRoot.FulcioRoots {
+		roots.AddCert(root)
+	}
+
+	rekorKeys, err := transparencyLogKeys(trustedRoot.RekorPublicKeys)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create sigstore verifier: %w", err)
+	}
+	ctLogKeys, err := transparencyLogKeys(trustedRoot.CTLogPublicKeys)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create sigstore verifier: %w", err)
+	}
+
+	return &SigstoreVerifier{
+		keyID:       key.KeyID,
+		identity:    key.KeyVal.Identity,
+		issuer:      key.KeyVal.Issuer,
+		roots:       roots,
+		issuers:     slices.Concat(trustedRoot.FulcioIntermediates, trustedRoot.FulcioRoots),
+		certOptions: []CertificateVerifierOption{WithIntermediates(trustedRoot.FulcioIntermediates...)},
+		rekorKeys:   rekorKeys,
+		ctLogKeys:   ctLogKeys,
+	}, nil
+}
+
+// Verify verifies the `sig` value passed in against `data`. The signature is
+// the JSON encoded Sigstore bundle. The bundle's transparency log entry must
+// carry a signed entry timestamp from a trusted Rekor log, which also
+// authenticates the time the entry was integrated into the log, and must
+// record the signature over `data`. An inclusion proof with a signed
+// checkpoint is verified too if present. The certificate must chain to a
+// trusted Fulcio root at the time the entry was integrated into the log, must
+// carry a signed certificate timestamp from a trusted CT log, and must match
+// the key's identity and issuer.
+func (v *SigstoreVerifier) Verify(ctx context.Context, data []byte, sig []byte) error {
+	bundle := &sigstoreBundle{}
+	if err := json.Unmarshal(sig, bundle); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSigstoreBundle, err)
+	}
+	if bundle.MessageSignature == nil || len(bundle.MessageSignature.Signature) == 0 {
+		return fmt.Errorf("%w: missing message signature", ErrInvalidSigstoreBundle)
+	}
+
+	certificates, err := bundle.VerificationMaterial.certificates()
+	if err != nil {
+		return err
+	}
+	leaf := certificates[0]
+
+	digest := sha256.Sum256(data)
+	if d := bundle.MessageSignature.MessageDigest; d != nil && (d.Algorithm != "SHA2_256" || !bytes.Equal(d.Digest, digest[:])) {
+		return fmt.Errorf("%w: message digest does not match data", ErrSignatureVerificationFailed)
+	}
+
+	integratedTime, err := v.verifyTlogEntries(bundle.VerificationMaterial.TlogEntries, leaf, bundle.MessageSignature.Signature, digest[:])
+	if err != nil {
+		return err
+	}
+
+	chain := ""
+	for _, certificate := range certificates {
+		chain += string(generatePEMBlock(certificate.Raw, CertificatePEM))
+	}
+	certificateKey := &SSLibKey{
+		KeyID: v.keyID,
+		KeyVal: KeyVal{
+			Certificate: chain,
+			Identity:    v.identity,
+			Issuer:      v.issuer,
+		},
+	}
+	certOptions := append([]CertificateVerifierOption{WithVerificationTime(integratedTime)}, v.certOptions...)
+	verifier, err := NewCertificateVerifierFromSSLibKey(certificateKey, v.roots, certOptions...)
+	if err != nil {
+		return err
+	}
+
+	if err := verifyEmbeddedSCTs(verifier.Certificate(), slices.Concat(certificates[1:], v.issuers), v.ctLogKeys); err != nil {
+		return err
+	}
+
+	return verifier.Verify(ctx, data, bundle.MessageSignature.Signature)
+}
+
+// KeyID returns the identifier of the key used to create the
+// SigstoreVerifier instance.
+func (v *SigstoreVerifier) KeyID() (string, error) {
+	return v.keyID, nil
+}
+
+// Public returns nil, as keyless signatures are not tied to a fixed public
+// key.
+func (v *SigstoreVerifier) Public() crypto.PublicKey {
+	return nil
+}
+
+// verifyTlogEntries checks that at least one of the entries was logged by a
+// trusted Rekor log and records the signature, and returns the time the entry
+// was integrated into the log. Only the signed entry timestamp authenticates
+// that time, so entries without one are rejected.
+func (v *SigstoreVerifier) verifyTlogEntries(entries []sigstoreTlogEntry, leaf *x509.Certificate, signature, digest []byte) (time.Time, error) {
+	if len(entries) == 0 {
+		return time.Time{}, fmt.Errorf("%w: no transparency log entries", ErrInvalidSigstoreBundle)
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		logID := hex.EncodeToString(entry.LogID.KeyID)
+		logKey, ok := v.rekorKeys[logID]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: unknown log %s", ErrTransparencyLogVerificationFailed, logID))
+			continue
+		}
+
+		if err := verifyTlogEntry(&entry, logID, logKey); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := verifyHashedRekordBody(entry.CanonicalizedBody, leaf, signature, digest); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		return time.Unix(entry.IntegratedTime, 0), nil
+	}
+
+	return time.Time{}, errors.Join(errs...)
+}
+
+// verifyTlogEntry checks the inclusion of the entry in the log using its
+// signed entry timestamp and, if present, its inclusion proof.
+func verifyTlogEntry(entry *sigstoreTlogEntry, logID string, logKey crypto.PublicKey) error {
+	// The inclusion proof only commits to the body of the entry, not to the
+	// integrated time.
+	if entry.InclusionPromise == nil {
+		return fmt.Errorf("%w: no signed entry timestamp", ErrTransparencyLogVerificationFailed)
+	}
+
+	if proof := entry.InclusionProof; proof != nil {
+		if proof.LogIndex != entry.LogIndex {
+			return fmt.Errorf("%w: inclusion proof does not match log index", ErrTransparencyLogVerificationFailed)
+		}
+		if err := verifyCheckpoint(proof.Checkpoint.Envelope, proof.TreeSize, proof.RootHash, logKey); err != nil {
+			return err
+		}
+		if err := verifyInclusionProof(proof.LogIndex, proof.TreeSize, hashLeaf(entry.CanonicalizedBody), proof.Hashes, proof.RootHash); err != nil {
+			return err
+		}
+	}
+
+	payload, err := cjson.EncodeCanonical(map[string]any{
+		"body":           base64.StdEncoding.EncodeToString(entry.CanonicalizedBody),
+		"integratedTime": entry.IntegratedTime,
+		"logID":          logID,
+		"logIndex":       entry.LogIndex,
+	})
+	if err != nil {
+		return err
+	}
+	if err := verifyWithPublicKey(logKey, payload, entry.InclusionPromise.SignedEntryTimestamp); err != nil {
+		return fmt.Errorf("%w: invalid signed entry timestamp", ErrTransparencyLogVerificationFailed)
+	}
+
+	return nil
+}
+
+// verifyHashedRekordBody checks that the log entry records the signature over
+// the digest made with the certificate's key.
+func verifyHashedRekordBody(body []byte, leaf *x509.Certificate, signature, digest []byte) error {
+	entry := &hashedRekord{}
+	if err := json.Unmarshal(body, entry); err != nil {
+		return fmt.Errorf("%w: %w", ErrTransparencyLogVerificationFailed, err)
+	}
+	if entry.Kind != "hashedrekord" || entry.APIVersion != "0.0.1" {
+		return fmt.Errorf("%w: unsupported entry kind %s %s", ErrTransparencyLogVerificationFailed, entry.Kind, entry.APIVersion)
+	}
+
+	if entry.Spec.Data.Hash.Algorithm != "sha256" || entry.Spec.Data.Hash.Value != hex.EncodeToString(digest) {
+		return fmt.Errorf("%w: entry does not match data", ErrTransparencyLogVerificationFailed)
+	}
+	if !bytes.Equal(entry.Spec.Signature.Content, signature) {
+		return fmt.Errorf("%w: entry does not match signature", ErrTransparencyLogVerificationFailed)
+	}
+
+	certificates, err := parseCertificates(entry.Spec.Signature.PublicKey.Content)
+	if err != nil || !certificates[0].Equal(leaf) {
+		return fmt.Errorf("%w: entry does not match certificate", ErrTransparencyLogVerificationFailed)
+	}
+
+	return nil
+}
+
+// certificates returns the certificates in the verification material, leaf
+// first.
+func (m *sigstoreVerificationMaterial) certificates() ([]*x509.Certificate, error) {
+	var rawCertificates [][]byte
+	switch {
+	case m.Certificate != nil:
+		rawCertificates = append(rawCertificates, m.Certificate.RawBytes)
+	case m.X509CertificateChain != nil:
+		for _, certificate := range m.X509CertificateChain.Certificates {
+			rawCertificates = append(rawCertificates, certificate.RawBytes)
+		}
+	}
+	if len(rawCertificates) == 0 {
+		return nil, fmt.Errorf("%w: missing certificate", ErrInvalidSigstoreBundle)
+	}
+
+	certificates := make([]*x509.Certificate, 0, len(rawCertificates))
+	for _, raw := range rawCertificates {
+		certificate, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w: %w", ErrInvalidSigstoreBundle, ErrInvalidCertificate, err)
+		}
+		certificates = append(certificates, certificate)
+	}
+	return certificates, nil
+}
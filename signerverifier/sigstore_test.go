@@ -0,0 +1,619 @@
+package signerverifier
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/secure-systems-lab/go-securesystemslib/cjson"
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// testSigstoreInstance mimics a Sigstore deployment: a Fulcio CA, a Rekor log
+// and a certificate transparency log.
+type testSigstoreInstance struct {
+	ca       *testCertificateAuthority
+	rekorKey *ecdsa.PrivateKey
+	ctLogKey *ecdsa.PrivateKey
+}
+
+func newTestSigstoreInstance(t *testing.T) *testSigstoreInstance {
+	t.Helper()
+
+	rekorKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctLogKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &testSigstoreInstance{
+		ca:       newTestCertificateAuthority(t),
+		rekorKey: rekorKey,
+		ctLogKey: ctLogKey,
+	}
+}
+
+func (s *testSigstoreInstance) trustedRoot() *SigstoreTrustedRoot {
+	return &SigstoreTrustedRoot{
+		FulcioRoots:         []*x509.Certificate{s.ca.root},
+		FulcioIntermediates: []*x509.Certificate{s.ca.intermediate},
+		RekorPublicKeys:     []crypto.PublicKey{s.rekorKey.Public()},
+		CTLogPublicKeys:     []crypto.PublicKey{s.ctLogKey.Public()},
+	}
+}
+
+func testLogID(t *testing.T, publicKey crypto.PublicKey) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logID := sha256.Sum256(der)
+	return logID[:]
+}
+
+func testSignASN1(t *testing.T, key *ecdsa.PrivateKey, message []byte) []byte {
+	t.Helper()
+
+	digest := sha256.Sum256(message)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+// issue creates a leaf certificate with a signed certificate timestamp
+// embedded by ctLogKey, or none if ctLogKey is nil.
+func (s *testSigstoreInstance) issue(t *testing.T, ctLogKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := testLeafTemplate(t)
+	template.SerialNumber = big.NewInt(4242)
+	if ctLogKey == nil {
+		return createTestCertificate(t, template, s.ca.intermediate, leafKey.Public(), s.ca.intermediateKey), leafKey
+	}
+
+	// The timestamp is issued for the certificate without the timestamp
+	// extension, which is appended last.
+	precertificate := createTestCertificate(t, template, s.ca.intermediate, leafKey.Public(), s.ca.intermediateKey)
+	issuerKeyHash := sha256.Sum256(s.ca.intermediate.RawSubjectPublicKeyInfo)
+	timestamp := uint64(testSigningTime.UnixMilli())
+
+	signed := cryptobyte.NewBuilder(nil)
+	signed.AddUint8(0)
+	signed.AddUint8(0)
+	signed.AddUint64(timestamp)
+	signed.AddUint16(1)
+	signed.AddBytes(issuerKeyHash[:])
+	signed.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(precertificate.RawTBSCertificate) })
+	signed.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {})
+
+	sct := cryptobyte.NewBuilder(nil)
+	sct.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddUint8(0)
+			b.AddBytes(testLogID(t, ctLogKey.Public()))
+			b.AddUint64(timestamp)
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {})
+			b.AddUint8(4) // sha256
+			b.AddUint8(3) // ecdsa
+			b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+				b.AddBytes(testSignASN1(t, ctLogKey, signed.BytesOrPanic()))
+			})
+		})
+	})
+	sctExtension, err := asn1.Marshal(sct.BytesOrPanic())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template.ExtraExtensions = append(template.ExtraExtensions, pkix.Extension{Id: oidSCTList, Value: sctExtension})
+	return createTestCertificate(t, template, s.ca.intermediate, leafKey.Public(), s.ca.intermediateKey), leafKey
+}
+
+// sign signs data with the leaf key and logs the signature, returning a bundle
+// with both an inclusion proof and an inclusion promise.
+func (s *testSigstoreInstance) sign(t *testing.T, leaf *x509.Certificate, leafKey *ecdsa.PrivateKey, data []byte) *sigstoreBundle {
+	t.Helper()
+
+	digest := sha256.Sum256(data)
+	signature := testSignASN1(t, leafKey, data)
+
+	body, err := cjson.EncodeCanonical(map[string]any{
+		"apiVersion": "0.0.1",
+		"kind":       "hashedrekord",
+		"spec": map[string]any{
+			"data": map[string]any{
+				"hash": map[string]any{"algorithm": "sha256", "value": hex.EncodeToString(digest[:])},
+			},
+			"signature": map[string]any{
+				"content": base64.StdEncoding.EncodeToString(signature),
+				"publicKey": map[string]any{
+					"content": base64.StdEncoding.EncodeToString(generatePEMBlock(leaf.Raw, CertificatePEM)),
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The entry is the last leaf of a tree of size three.
+	left := hashChildren(hashLeaf([]byte("a")), hashLeaf([]byte("b")))
+	rootHash := hashChildren(left, hashLeaf(body))
+
+	entry := sigstoreTlogEntry{
+		LogIndex:       2,
+		LogID:          sigstoreLogID{KeyID: testLogID(t, s.rekorKey.Public())},
+		KindVersion:    sigstoreKindVersion{Kind: "hashedrekord", Version: "0.0.1"},
+		IntegratedTime: testSigningTime.Unix(),
+		InclusionProof: &sigstoreInclusionProof{
+			LogIndex:   2,
+			RootHash:   rootHash,
+			TreeSize:   3,
+			Hashes:     [][]byte{left},
+			Checkpoint: sigstoreCheckpoint{Envelope: s.checkpoint(t, "rekor.example.com - 1234", "rekor.example.com", 3, rootHash)},
+		},
+		CanonicalizedBody: body,
+	}
+	s.promise(t, &entry)
+
+	return &sigstoreBundle{
+		MediaType: "application/vnd.dev.sigstore.bundle.v0.3+json",
+		VerificationMaterial: sigstoreVerificationMaterial{
+			Certificate: &sigstoreCertificate{RawBytes: leaf.Raw},
+			TlogEntries: []sigstoreTlogEntry{entry},
+		},
+		MessageSignature: &sigstoreMessageSignature{
+			MessageDigest: &sigstoreMessageDigest{Algorithm: "SHA2_256", Digest: digest[:]},
+			Signature:     signature,
+		},
+	}
+}
+
+// promise sets the signed entry timestamp of the entry.
+func (s *testSigstoreInstance) promise(t *testing.T, entry *sigstoreTlogEntry) {
+	t.Helper()
+
+	promise, err := cjson.EncodeCanonical(map[string]any{
+		"body":           base64.StdEncoding.EncodeToString(entry.CanonicalizedBody),
+		"integratedTime": entry.IntegratedTime,
+		"logID":          hex.EncodeToString(entry.LogID.KeyID),
+		"logIndex":       entry.LogIndex,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.InclusionPromise = &sigstoreInclusionPromise{SignedEntryTimestamp: testSignASN1(t, s.rekorKey, promise)}
+}
+
+// checkpoint returns a checkpoint for the tree with the given origin, signed
+// by the log under the given name.
+func (s *testSigstoreInstance) checkpoint(t *testing.T, origin, name string, treeSize int64, rootHash []byte) string {
+	t.Helper()
+
+	logID := testLogID(t, s.rekorKey.Public())
+	checkpoint := fmt.Sprintf("%s\n%d\n%s\n", origin, treeSize, base64.StdEncoding.EncodeToString(rootHash))
+	checkpointSig := append(append([]byte{}, logID[:4]...), testSignASN1(t, s.rekorKey, []byte(checkpoint))...)
+	return checkpoint + "\n— " + name + " " + base64.StdEncoding.EncodeToString(checkpointSig) + "\n"
+}
+
+func marshalBundle(t *testing.T, bundle *sigstoreBundle) []byte {
+	t.Helper()
+
+	bundleBytes, err := json.Marshal(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bundleBytes
+}
+
+func TestSigstoreVerifier(t *testing.T) {
+	instance := newTestSigstoreInstance(t)
+	message := []byte("test message")
+
+	key := &SSLibKey{
+		KeyType: SigstoreKeyType,
+		Scheme:  SigstoreKeyScheme,
+		KeyID:   "sigstore-keyid",
+		KeyVal: KeyVal{
+			Identity: "user@example.com",
+			Issuer:   "https://accounts.example.com",
+		},
+	}
+	assert.Nil(t, key.Validate())
+
+	verifier, err := NewSigstoreVerifierFromSSLibKey(key, instance.trustedRoot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyID, err := verifier.KeyID()
+	assert.Nil(t, err)
+	assert.Equal(t, "sigstore-keyid", keyID)
+
+	leaf, leafKey := instance.issue(t, instance.ctLogKey)
+	bundle := instance.sign(t, leaf, leafKey, message)
+
+	t.Run("valid bundle", func(t *testing.T) {
+		assert.Nil(t, verifier.Verify(context.Background(), message, marshalBundle(t, bundle)))
+	})
+
+	t.Run("certificate chain in bundle", func(t *testing.T) {
+		bundle := *bundle
+		bundle.VerificationMaterial.Certificate = nil
+		bundle.VerificationMaterial.X509CertificateChain = &sigstoreX509CertificateChain{
+			Certificates: []sigstoreCertificate{{RawBytes: leaf.Raw}, {RawBytes: instance.ca.intermediate.Raw}},
+		}
+		assert.Nil(t, verifier.Verify(context.Background(), message, marshalBundle(t, &bundle)))
+	})
+
+	t.Run("inclusion promise only", func(t *testing.T) {
+		bundle := instance.sign(t, leaf, leafKey, message)
+		bundle.VerificationMaterial.TlogEntries[0].InclusionProof = nil
+		assert.Nil(t, verifier.Verify(context.Background(), message, marshalBundle(t, bundle)))
+	})
+
+	t.Run("with DSSE envelope", func(t *testing.T) {
+		payloadType := "application/vnd.dsse+json"
+		bundle := instance.sign(t, leaf, leafKey, dsse.PAE(payloadType, message))
+		env := &dsse.Envelope{
+			PayloadType: payloadType,
+			Payload:     base64.StdEncoding.EncodeToString(message),
+			Signatures: []dsse.Signature{{
+				KeyID: "sigstore-keyid",
+				Sig:   base64.StdEncoding.EncodeToString(marshalBundle(t, bundle)),
+			}},
+		}
+
+		ev, err := dsse.NewEnvelopeVerifier(verifier)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ev.Verify(context.Background(), env)
+		assert.Nil(t, err)
+	})
+
+	otherInstance := newTestSigstoreInstance(t)
+	untrustedCTLeaf, untrustedCTLeafKey := instance.issue(t, otherInstance.ctLogKey)
+	noSCTLeaf, noSCTLeafKey := instance.issue(t, nil)
+
+	tests := map[string]struct {
+		bundle      func() *sigstoreBundle
+		key         func(key SSLibKey) SSLibKey
+		data        []byte
+		expectedErr error
+	}{
+		"different data": {
+			data:        []byte("another message"),
+			expectedErr: ErrSignatureVerificationFailed,
+		},
+		"different data without message digest": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				bundle.MessageSignature.MessageDigest = nil
+				return bundle
+			},
+			data:        []byte("another message"),
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"signature not logged": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				bundle.MessageSignature.Signature = testSignASN1(t, leafKey, message)
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"no transparency log entries": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				bundle.VerificationMaterial.TlogEntries = nil
+				return bundle
+			},
+			expectedErr: ErrInvalidSigstoreBundle,
+		},
+		"no inclusion proof or promise": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				bundle.VerificationMaterial.TlogEntries[0].InclusionProof = nil
+				bundle.VerificationMaterial.TlogEntries[0].InclusionPromise = nil
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"inclusion proof only": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				bundle.VerificationMaterial.TlogEntries[0].InclusionPromise = nil
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"inclusion proof only with tampered integrated time": {
+			// Nothing but the signed entry timestamp authenticates the
+			// integrated time, which must not be trusted to validate an
+			// expired certificate.
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				entry := &bundle.VerificationMaterial.TlogEntries[0]
+				entry.IntegratedTime = testSigningTime.Add(5 * time.Minute).Unix()
+				entry.InclusionPromise = nil
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"untrusted log": {
+			bundle: func() *sigstoreBundle {
+				return otherInstance.sign(t, leaf, leafKey, message)
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"tampered signed entry timestamp": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				bundle.VerificationMaterial.TlogEntries[0].InclusionProof = nil
+				bundle.VerificationMaterial.TlogEntries[0].IntegratedTime++
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"tampered inclusion proof": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				bundle.VerificationMaterial.TlogEntries[0].InclusionProof.Hashes[0][0] ^= 1
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"wrong leaf index": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				bundle.VerificationMaterial.TlogEntries[0].InclusionProof.LogIndex = 1
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"inclusion proof for different log index": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				entry := &bundle.VerificationMaterial.TlogEntries[0]
+				entry.LogIndex = 1
+				instance.promise(t, entry)
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"checkpoint from different log": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				proof := bundle.VerificationMaterial.TlogEntries[0].InclusionProof
+				proof.Checkpoint.Envelope = instance.checkpoint(t, "other.example.com - 1234", "rekor.example.com", proof.TreeSize, proof.RootHash)
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"checkpoint for different tree": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				proof := bundle.VerificationMaterial.TlogEntries[0].InclusionProof
+				proof.Checkpoint.Envelope = strings.Replace(proof.Checkpoint.Envelope, "\n3\n", "\n4\n", 1)
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"unsigned checkpoint": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				proof := bundle.VerificationMaterial.TlogEntries[0].InclusionProof
+				proof.Checkpoint.Envelope = strings.SplitAfter(proof.Checkpoint.Envelope, "\n\n")[0]
+				return bundle
+			},
+			expectedErr: ErrTransparencyLogVerificationFailed,
+		},
+		"integrated after certificate expired": {
+			bundle: func() *sigstoreBundle {
+				bundle := instance.sign(t, leaf, leafKey, message)
+				entry := &bundle.VerificationMaterial.TlogEntries[0]
+				entry.IntegratedTime = testSigningTime.Add(time.Hour).Unix()
+				instance.promise(t, entry)
+				return bundle
+			},
+			expectedErr: ErrInvalidCertificate,
+		},
+		"untrusted certificate transparency log": {
+			bundle: func() *sigstoreBundle {
+				return instance.sign(t, untrustedCTLeaf, untrustedCTLeafKey, message)
+			},
+			expectedErr: ErrCertificateTransparencyVerificationFailed,
+		},
+		"no signed certificate timestamp": {
+			bundle: func() *sigstoreBundle {
+				return instance.sign(t, noSCTLeaf, noSCTLeafKey, message)
+			},
+			expectedErr: ErrCertificateTransparencyVerificationFailed,
+		},
+		"identity mismatch": {
+			key: func(key SSLibKey) SSLibKey {
+				key.KeyVal.Identity = "attacker@example.com"
+				return key
+			},
+			expectedErr: ErrCertificateIdentityMismatch,
+		},
+		"issuer mismatch": {
+			key: func(key SSLibKey) SSLibKey {
+				key.KeyVal.Issuer = "https://attacker.example.com"
+				return key
+			},
+			expectedErr: ErrCertificateIssuerMismatch,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			testBundle := bundle
+			if test.bundle != nil {
+				testBundle = test.bundle()
+			}
+			testVerifier := verifier
+			if test.key != nil {
+				testKey := test.key(*key)
+				testVerifier, err = NewSigstoreVerifierFromSSLibKey(&testKey, instance.trustedRoot())
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			data := message
+			if test.data != nil {
+				data = test.data
+			}
+
+			err := testVerifier.Verify(context.Background(), data, marshalBundle(t, testBundle))
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+
+	t.Run("malformed bundle", func(t *testing.T) {
+		err := verifier.Verify(context.Background(), message, []byte("not a bundle"))
+		assert.ErrorIs(t, err, ErrInvalidSigstoreBundle)
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		_, err := NewSigstoreVerifierFromSSLibKey(&SSLibKey{KeyType: SigstoreKeyType, Scheme: SigstoreKeyScheme}, instance.trustedRoot())
+		assert.ErrorIs(t, err, ErrInvalidKey)
+
+		ecdsaKey := *key
+		ecdsaKey.KeyType = ECDSAKeyType
+		_, err = NewSigstoreVerifierFromSSLibKey(&ecdsaKey, instance.trustedRoot())
+		assert.ErrorIs(t, err, ErrUnknownKeyType)
+
+		_, err = NewSigstoreVerifierFromSSLibKey(key, &SigstoreTrustedRoot{})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestValidateSigstoreKey(t *testing.T) {
+	tests := map[string]struct {
+		modify        func(key *SSLibKey)
+		expectedField string
+		expectedErr   error
+	}{
+		"unknown scheme": {
+			modify:        func(key *SSLibKey) { key.Scheme = "fulcio" },
+			expectedField: "scheme",
+			expectedErr:   ErrUnknownScheme,
+		},
+		"missing identity": {
+			modify:        func(key *SSLibKey) { key.KeyVal.Identity = "" },
+			expectedField: "keyval.identity",
+			expectedErr:   ErrInvalidKey,
+		},
+		"missing issuer": {
+			modify:        func(key *SSLibKey) { key.KeyVal.Issuer = "" },
+			expectedField: "keyval.issuer",
+			expectedErr:   ErrInvalidKey,
+		},
+		"missing keyid": {
+			modify:        func(key *SSLibKey) { key.KeyID = "" },
+			expectedField: "keyid",
+			expectedErr:   ErrKeyIDMismatch,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key := &SSLibKey{
+				KeyType: SigstoreKeyType,
+				Scheme:  SigstoreKeyScheme,
+				KeyID:   "sigstore-keyid",
+				KeyVal: KeyVal{
+					Identity: "user@example.com",
+					Issuer:   "https://accounts.example.com",
+				},
+			}
+			test.modify(key)
+
+			err := key.Validate()
+			assert.ErrorIs(t, err, test.expectedErr)
+
+			var validationErr *KeyValidationError
+			if assert.True(t, errors.As(err, &validationErr)) {
+				assert.Equal(t, test.expectedField, validationErr.Field)
+			}
+		})
+	}
+}
+
+func TestVerifyInclusionProof(t *testing.T) {
+	// treeHash and inclusionProof compute the Merkle tree hash and inclusion
+	// proofs as defined in RFC 6962, section 2.1.
+	var treeHash func(leaves [][]byte) []byte
+	treeHash = func(leaves [][]byte) []byte {
+		if len(leaves) == 1 {
+			return hashLeaf(leaves[0])
+		}
+		k := 1
+		for k*2 < len(leaves) {
+			k *= 2
+		}
+		return hashChildren(treeHash(leaves[:k]), treeHash(leaves[k:]))
+	}
+	var inclusionProof func(index int, leaves [][]byte) [][]byte
+	inclusionProof = func(index int, leaves [][]byte) [][]byte {
+		if len(leaves) == 1 {
+			return nil
+		}
+		k := 1
+		for k*2 < len(leaves) {
+			k *= 2
+		}
+		if index < k {
+			return append(inclusionProof(index, leaves[:k]), treeHash(leaves[k:]))
+		}
+		return append(inclusionProof(index-k, leaves[k:]), treeHash(leaves[:k]))
+	}
+
+	leaves := [][]byte{}
+	for size := 1; size <= 17; size++ {
+		leaves = append(leaves, []byte{byte(size)})
+		root := treeHash(leaves)
+
+		for index := range leaves {
+			proof := inclusionProof(index, leaves)
+			assert.Nil(t, verifyInclusionProof(int64(index), int64(size), hashLeaf(leaves[index]), proof, root), "index %d of %d", index, size)
+
+			if index > 0 {
+				assert.NotNil(t, verifyInclusionProof(int64(index-1), int64(size), hashLeaf(leaves[index]), proof, root), "index %d of %d", index, size)
+			}
+			assert.NotNil(t, verifyInclusionProof(int64(index), int64(size), hashLeaf(leaves[index]), append(proof, root), root), "index %d of %d", index, size)
+		}
+	}
+
+	assert.NotNil(t, verifyInclusionProof(3, 3, hashLeaf([]byte{1}), nil, nil))
+}
@@ -0,0 +1,66 @@
+package signerverifier
+
+import (
+	"crypto/ed25519"
+	"encoding/pem"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const OpenSSHPrivateKeyPEM = "OPENSSH PRIVATE KEY"
+
+// LoadSSHKey returns an SSLibKey object when provided an OpenSSH private key or
+// a public key in the authorized_keys format. The passphrase is used to
+// decrypt protected private keys and may be nil otherwise. The resulting key
+// is identical to the one LoadKey returns for the PEM encoding of the same key,
+// including its keyid.
+func LoadSSHKey(keyBytes []byte, passphrase []byte) (*SSLibKey, error) {
+	if block, _ := pem.Decode(keyBytes); block != nil {
+		var rawKey any
+		var err error
+		if len(passphrase) > 0 {
+			rawKey, err = ssh.ParseRawPrivateKeyWithPassphrase(keyBytes, passphrase)
+		} else {
+			rawKey, err = ssh.ParseRawPrivateKey(keyBytes)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to load SSH key: %w", err)
+		}
+
+		// OpenSSH ED25519 keys are returned as a pointer.
+		if k, ok := rawKey.(*ed25519.PrivateKey); ok {
+			rawKey = *k
+		}
+
+		return newSSLibKeyFromPrivateKey(rawKey)
+	}
+
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey(keyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load SSH key: %w", err)
+	}
+
+	cryptoPublicKey, ok := publicKey.(ssh.CryptoPublicKey)
+	if !ok {
+		return nil, ErrUnknownKeyType
+	}
+
+	key, err := newSSLibKeyFromPublicKey(cryptoPublicKey.CryptoPublicKey())
+	if err != nil {
+		return nil, fmt.Errorf("unable to load SSH key: %w", err)
+	}
+
+	return key, nil
+}
+
+// isSSHKey reports whether keyBytes holds an OpenSSH private key or an
+// authorized_keys style public key rather than a PEM encoded key.
+func isSSHKey(keyBytes []byte) bool {
+	if block, _ := pem.Decode(keyBytes); block != nil {
+		return block.Type == OpenSSHPrivateKeyPEM
+	}
+
+	_, _, _, _, err := ssh.ParseAuthorizedKey(keyBytes)
+	return err == nil
+}
@@ -0,0 +1,150 @@
+package signerverifier
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+//go:embed test-data/ecdsa-test-key-openssh
+var ecdsaOpenSSHPrivateKey []byte
+
+//go:embed test-data/ecdsa-test-key-openssh.pub
+var ecdsaOpenSSHPublicKey []byte
+
+//go:embed test-data/ed25519-test-key-openssh
+var ed25519OpenSSHPrivateKey []byte
+
+//go:embed test-data/ed25519-test-key-openssh.pub
+var ed25519OpenSSHPublicKey []byte
+
+//go:embed test-data/ed25519-test-key-openssh-encrypted
+var ed25519OpenSSHEncryptedPrivateKey []byte
+
+func TestLoadKeyWithSSHKeys(t *testing.T) {
+	expectedECDSAKeyID := "98adf38602c48c5479e9a991ee3f8cbf541ee4f985e00f7a5fc4148d9a45b704"
+	expectedED25519PublicKey := "7103d7639fd2892a35aaec1df32404f4a8c95eb7eac6d2cd127f5f4acc681e94"
+	expectedED25519KeyID := "a23632a529d60007cd5f1cd2bdf57948e32524ab2c8771ccd67d78015c63e54d"
+
+	tests := map[string]struct {
+		keyBytes        []byte
+		expectedKeyID   string
+		expectedKeyType string
+		expectedScheme  string
+		expectPrivate   bool
+	}{
+		"OpenSSH ECDSA private key": {
+			keyBytes:        ecdsaOpenSSHPrivateKey,
+			expectedKeyID:   expectedECDSAKeyID,
+			expectedKeyType: ECDSAKeyType,
+			expectedScheme:  ECDSAKeyScheme,
+			expectPrivate:   true,
+		},
+		"OpenSSH ECDSA public key": {
+			keyBytes:        ecdsaOpenSSHPublicKey,
+			expectedKeyID:   expectedECDSAKeyID,
+			expectedKeyType: ECDSAKeyType,
+			expectedScheme:  ECDSAKeyScheme,
+		},
+		"OpenSSH ED25519 private key": {
+			keyBytes:        ed25519OpenSSHPrivateKey,
+			expectedKeyID:   expectedED25519KeyID,
+			expectedKeyType: ED25519KeyType,
+			expectedScheme:  ED25519KeyType,
+			expectPrivate:   true,
+		},
+		"OpenSSH ED25519 public key": {
+			keyBytes:        ed25519OpenSSHPublicKey,
+			expectedKeyID:   expectedED25519KeyID,
+			expectedKeyType: ED25519KeyType,
+			expectedScheme:  ED25519KeyType,
+		},
+		"authorized_keys line with options": {
+			keyBytes:        append([]byte(`restrict,command="true" `), ed25519OpenSSHPublicKey...),
+			expectedKeyID:   expectedED25519KeyID,
+			expectedKeyType: ED25519KeyType,
+			expectedScheme:  ED25519KeyType,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key, err := LoadKey(test.keyBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.Equal(t, test.expectedKeyID, key.KeyID)
+			assert.Equal(t, test.expectedKeyType, key.KeyType)
+			assert.Equal(t, test.expectedScheme, key.Scheme)
+			assert.Equal(t, test.expectPrivate, key.KeyVal.Private != "")
+			if test.expectedKeyType == ED25519KeyType {
+				assert.Equal(t, expectedED25519PublicKey, key.KeyVal.Public)
+			}
+
+			// The PEM encoding of the same key must result in the same
+			// keyid.
+			publicPEM, err := key.MarshalPublicKeyPEM()
+			if err != nil {
+				t.Fatal(err)
+			}
+			pemKey, err := LoadKey(publicPEM)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, key.KeyID, pemKey.KeyID)
+		})
+	}
+
+	t.Run("OpenSSH ECDSA key matches PEM key", func(t *testing.T) {
+		sshKey, err := LoadKey(ecdsaOpenSSHPrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pemKey, err := LoadKey(ecdsaPrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, pemKey, sshKey)
+	})
+
+	t.Run("encrypted OpenSSH key without passphrase", func(t *testing.T) {
+		_, err := LoadKey(ed25519OpenSSHEncryptedPrivateKey)
+		var missingErr *ssh.PassphraseMissingError
+		assert.True(t, errors.As(err, &missingErr))
+	})
+}
+
+func TestLoadSSHKey(t *testing.T) {
+	t.Run("encrypted OpenSSH key", func(t *testing.T) {
+		key, err := LoadSSHKey(ed25519OpenSSHEncryptedPrivateKey, []byte("test passphrase"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, ED25519KeyType, key.KeyType)
+		assert.NotEmpty(t, key.KeyVal.Private)
+
+		sv, err := NewSignerVerifierFromSSLibKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		message := []byte("test message")
+		signature, err := sv.Sign(context.Background(), message)
+		assert.Nil(t, err)
+		assert.Nil(t, sv.Verify(context.Background(), message, signature))
+	})
+
+	t.Run("encrypted OpenSSH key with wrong passphrase", func(t *testing.T) {
+		_, err := LoadSSHKey(ed25519OpenSSHEncryptedPrivateKey, []byte("wrong passphrase"))
+		assert.ErrorContains(t, err, "unable to load SSH key")
+	})
+
+	t.Run("invalid public key", func(t *testing.T) {
+		_, err := LoadSSHKey([]byte("ssh-ed25519 invalid"), nil)
+		assert.ErrorContains(t, err, "unable to load SSH key")
+	})
+}
@@ -0,0 +1,153 @@
+package signerverifier
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// sshAgentAlgorithms maps securesystemslib schemes to the SSH signature
+// algorithm an agent uses to create signatures in the same format. RSA-PSS is
+// not supported by the SSH agent protocol.
+var sshAgentAlgorithms = map[string]string{
+	ED25519KeyType:             ssh.KeyAlgoED25519,
+	ECDSAKeyScheme:             ssh.KeyAlgoECDSA256,
+	ECDSAP384KeyScheme:         ssh.KeyAlgoECDSA384,
+	ECDSAP521KeyScheme:         ssh.KeyAlgoECDSA521,
+	RSAPKCS1v15SHA256KeyScheme: ssh.KeyAlgoRSASHA256,
+	RSAPKCS1v15SHA512KeyScheme: ssh.KeyAlgoRSASHA512,
+}
+
+// sshAgentSignerVerifier signs with a key held by an ssh-agent and produces
+// the same signatures as the signerverifier for the key's scheme. A new
+// connection to the agent is made for each signature, so that no connection
+// is left open.
+type sshAgentSignerVerifier struct {
+	socketPath string
+	algorithm  string
+	public     ssh.PublicKey
+	verifier   dsse.Verifier
+}
+
+func sshAgentSignerFromURI(_ context.Context, uri *url.URL, pubKey *SSLibKey, _ *SignerURIOptions) (dsse.SignerVerifier, error) {
+	if pubKey == nil {
+		return nil, fmt.Errorf("unable to create signer for %q: %w: public key required", uri, ErrInvalidKey)
+	}
+
+	algorithm, ok := sshAgentAlgorithms[pubKey.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unable to create signer for %q: %w: %q is not supported by ssh-agent", uri, ErrUnknownScheme, pubKey.Scheme)
+	}
+
+	verifier, err := NewSignerVerifierFromSSLibKey(pubKey.PublicOnly())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+	}
+	public, err := ssh.NewPublicKey(verifier.Public())
+	if err != nil {
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+	}
+
+	sv := &sshAgentSignerVerifier{
+		socketPath: uriPath(uri),
+		algorithm:  algorithm,
+		public:     public,
+		verifier:   verifier,
+	}
+	if sv.socketPath == "" {
+		sv.socketPath = os.Getenv("SSH_AUTH_SOCK")
+	}
+
+	// Fail early if the agent does not hold the key.
+	conn, _, err := sv.connect()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+	}
+	conn.Close() //nolint:errcheck
+
+	return sv, nil
+}
+
+// connect returns a connection to the agent and the agent's signer for the
+// key.
+func (sv *sshAgentSignerVerifier) connect() (net.Conn, ssh.AlgorithmSigner, error) {
+	conn, err := net.Dial("unix", sv.socketPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to connect to SSH agent: %w", err)
+	}
+
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		conn.Close() //nolint:errcheck
+		return nil, nil, fmt.Errorf("unable to list SSH agent keys: %w", err)
+	}
+
+	for _, signer := range signers {
+		if !bytes.Equal(signer.PublicKey().Marshal(), sv.public.Marshal()) {
+			continue
+		}
+		algorithmSigner, ok := signer.(ssh.AlgorithmSigner)
+		if !ok {
+			break
+		}
+		return conn, algorithmSigner, nil
+	}
+
+	conn.Close() //nolint:errcheck
+	return nil, nil, ErrSSHAgentNoKey
+}
+
+// Sign creates a signature for `data`.
+func (sv *sshAgentSignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
+	conn, signer, err := sv.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close() //nolint:errcheck
+
+	signature, err := signer.SignWithAlgorithm(rand.Reader, data, sv.algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("unable to sign with SSH agent: %w", err)
+	}
+
+	switch sv.public.Type() {
+	case ssh.KeyAlgoECDSA256, ssh.KeyAlgoECDSA384, ssh.KeyAlgoECDSA521:
+		// SSH encodes ECDSA signatures as two mpints rather than ASN.1.
+		var ecdsaSig struct {
+			R *big.Int
+			S *big.Int
+		}
+		if err := ssh.Unmarshal(signature.Blob, &ecdsaSig); err != nil {
+			return nil, fmt.Errorf("unable to sign with SSH agent: %w", err)
+		}
+		return asn1.Marshal(ecdsaSig)
+	default:
+		return signature.Blob, nil
+	}
+}
+
+// Verify verifies the `sig` value passed in against `data`.
+func (sv *sshAgentSignerVerifier) Verify(ctx context.Context, data []byte, sig []byte) error {
+	return sv.verifier.Verify(ctx, data, sig)
+}
+
+// KeyID returns the identifier of the key.
+func (sv *sshAgentSignerVerifier) KeyID() (string, error) {
+	return sv.verifier.KeyID()
+}
+
+// Public returns the public portion of the key.
+func (sv *sshAgentSignerVerifier) Public() crypto.PublicKey {
+	return sv.verifier.Public()
+}
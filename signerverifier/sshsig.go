@@ -0,0 +1,417 @@
+package signerverifier
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"net"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	SSHSignaturePEM = "SSH SIGNATURE"
+
+	sshSigMagic         = "SSHSIG"
+	sshSigVersion       = 1
+	sshSigHashAlgorithm = "sha512"
+)
+
+var (
+	ErrSSHSigNamespace = errors.New("SSH signature namespace must not be empty")
+	ErrSSHAgentNoKey   = errors.New("key not found in SSH agent")
+)
+
+// sshSignature is the wire format of an SSHSIG signature as described in
+// OpenSSH's PROTOCOL.sshsig.
+type sshSignature struct {
+	Magic         [6]byte
+	Version       uint32
+	PublicKey     []byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Signature     []byte
+}
+
+// sshSignedData is the blob that is actually passed to the SSH key when
+// creating an SSHSIG signature.
+type sshSignedData struct {
+	Magic         [6]byte
+	Namespace     string
+	Reserved      string
+	HashAlgorithm string
+	Hash          []byte
+}
+
+// SSHSigSignerVerifier is a dsse.SignerVerifier compliant interface to sign
+// and verify signatures in the SSHSIG format produced by `ssh-keygen -Y sign`.
+// Signatures are bound to a namespace, e.g. "git" or "file", and only verify
+// for the namespace they were created with.
+type SSHSigSignerVerifier struct {
+	keyID       string
+	namespace   string
+	signer      ssh.Signer
+	public      ssh.PublicKey
+	validAfter  time.Time
+	validBefore time.Time
+	conn        net.Conn
+}
+
+// NewSSHSigSignerVerifier creates an SSHSigSignerVerifier that signs with the
+// provided ssh.Signer, such as one returned by ssh.ParsePrivateKey.
+func NewSSHSigSignerVerifier(signer ssh.Signer, namespace string) (*SSHSigSignerVerifier, error) {
+	if signer == nil {
+		return nil, ErrInvalidKey
+	}
+
+	sv, err := NewSSHSigVerifier(signer.PublicKey(), namespace)
+	if err != nil {
+		return nil, err
+	}
+	sv.signer = signer
+
+	return sv, nil
+}
+
+// NewSSHSigVerifier creates an SSHSigSignerVerifier that can only verify
+// signatures created by the provided public key.
+func NewSSHSigVerifier(publicKey ssh.PublicKey, namespace string) (*SSHSigSignerVerifier, error) {
+	if publicKey == nil {
+		return nil, ErrInvalidKey
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("unable to create SSHSIG signerverifier: %w", ErrSSHSigNamespace)
+	}
+
+	return &SSHSigSignerVerifier{
+		keyID:     ssh.FingerprintSHA256(publicKey),
+		namespace: namespace,
+		public:    publicKey,
+	}, nil
+}
+
+// NewSSHSigSignerVerifierFromAgent creates an SSHSigSignerVerifier that signs
+// with a key held by the ssh-agent listening on socketPath. If socketPath is
+// empty, the SSH_AUTH_SOCK environment variable is used. If publicKey is nil,
+// the first key offered by the agent is used. The connection to the agent is
+// kept open until Close is called.
+func NewSSHSigSignerVerifierFromAgent(socketPath string, publicKey ssh.PublicKey, namespace string) (*SSHSigSignerVerifier, error) {
+	if socketPath == "" {
+		socketPath = os.Getenv("SSH_AUTH_SOCK")
+	}
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to SSH agent: %w", err)
+	}
+
+	signers, err := agent.NewClient(conn).Signers()
+	if err != nil {
+		conn.Close() //nolint:errcheck
+		return nil, fmt.Errorf("unable to list SSH agent keys: %w", err)
+	}
+
+	for _, signer := range signers {
+		if publicKey != nil && !bytes.Equal(signer.PublicKey().Marshal(), publicKey.Marshal()) {
+			continue
+		}
+
+		sv, err := NewSSHSigSignerVerifier(signer, namespace)
+		if err != nil {
+			conn.Close() //nolint:errcheck
+			return nil, err
+		}
+		sv.conn = conn
+		return sv, nil
+	}
+
+	conn.Close() //nolint:errcheck
+	return nil, ErrSSHAgentNoKey
+}
+
+// Sign creates an SSHSIG signature for `data` in its binary encoding. Use
+// ArmorSSHSignature to obtain the form written by `ssh-keygen -Y sign`.
+func (sv *SSHSigSignerVerifier) Sign(_ context.Context, data []byte) ([]byte, error) {
+	if sv.signer == nil {
+		return nil, ErrNotPrivateKey
+	}
+
+	signedData := sshSignedData{
+		Namespace:     sv.namespace,
+		HashAlgorithm: sshSigHashAlgorithm,
+		Hash:          hashBeforeSigning(data, sha512.New()),
+	}
+	copy(signedData.Magic[:], sshSigMagic)
+
+	var signature *ssh.Signature
+	var err error
+	if algorithmSigner, ok := sv.signer.(ssh.AlgorithmSigner); ok && sv.public.Type() == ssh.KeyAlgoRSA {
+		// SHA-1 based RSA signatures are rejected by OpenSSH.
+		signature, err = algorithmSigner.SignWithAlgorithm(rand.Reader, ssh.Marshal(signedData), ssh.KeyAlgoRSASHA512)
+	} else {
+		signature, err = sv.signer.Sign(rand.Reader, ssh.Marshal(signedData))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create SSHSIG signature: %w", err)
+	}
+
+	sig := sshSignature{
+		Version:       sshSigVersion,
+		PublicKey:     sv.public.Marshal(),
+		Namespace:     sv.namespace,
+		HashAlgorithm: sshSigHashAlgorithm,
+		Signature:     ssh.Marshal(signature),
+	}
+	copy(sig.Magic[:], sshSigMagic)
+
+	return ssh.Marshal(sig), nil
+}
+
+// Verify verifies the `sig` value passed in against `data`. Both the binary
+// and the armored encoding of SSHSIG signatures are accepted.
+func (sv *SSHSigSignerVerifier) Verify(_ context.Context, data []byte, sig []byte) error {
+	now := time.Now()
+	if (!sv.validAfter.IsZero() && now.Before(sv.validAfter)) || (!sv.validBefore.IsZero() && now.After(sv.validBefore)) {
+		return fmt.Errorf("%w: key is outside of its validity period", ErrSignatureVerificationFailed)
+	}
+
+	if block, _ := pem.Decode(sig); block != nil && block.Type == SSHSignaturePEM {
+		sig = block.Bytes
+	}
+
+	var parsed sshSignature
+	if err := ssh.Unmarshal(sig, &parsed); err != nil {
+		return ErrSignatureVerificationFailed
+	}
+	if string(parsed.Magic[:]) != sshSigMagic || parsed.Version != sshSigVersion {
+		return ErrSignatureVerificationFailed
+	}
+	if !bytes.Equal(parsed.PublicKey, sv.public.Marshal()) {
+		return ErrSignatureVerificationFailed
+	}
+	if parsed.Namespace != sv.namespace {
+		return fmt.Errorf("%w: unexpected namespace %q", ErrSignatureVerificationFailed, parsed.Namespace)
+	}
+
+	var h hash.Hash
+	switch parsed.HashAlgorithm {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return ErrSignatureVerificationFailed
+	}
+
+	signature := &ssh.Signature{}
+	if err := ssh.Unmarshal(parsed.Signature, signature); err != nil {
+		return ErrSignatureVerificationFailed
+	}
+	if signature.Format == ssh.KeyAlgoRSA {
+		return ErrSignatureVerificationFailed
+	}
+
+	signedData := sshSignedData{
+		Namespace:     parsed.Namespace,
+		Reserved:      parsed.Reserved,
+		HashAlgorithm: parsed.HashAlgorithm,
+		Hash:          hashBeforeSigning(data, h),
+	}
+	copy(signedData.Magic[:], sshSigMagic)
+
+	if err := sv.public.Verify(ssh.Marshal(signedData), signature); err != nil {
+		return ErrSignatureVerificationFailed
+	}
+
+	return nil
+}
+
+// KeyID returns the SHA256 fingerprint of the SSH key used to create the
+// SSHSigSignerVerifier instance, matching dsse.SHA256KeyID.
+func (sv *SSHSigSignerVerifier) KeyID() (string, error) {
+	return sv.keyID, nil
+}
+
+// Public returns the public portion of the key used to create the
+// SSHSigSignerVerifier instance.
+func (sv *SSHSigSignerVerifier) Public() crypto.PublicKey {
+	if cryptoPublicKey, ok := sv.public.(ssh.CryptoPublicKey); ok {
+		return cryptoPublicKey.CryptoPublicKey()
+	}
+	return sv.public
+}
+
+// Close releases the connection to the SSH agent, if any.
+func (sv *SSHSigSignerVerifier) Close() error {
+	if sv.conn == nil {
+		return nil
+	}
+	return sv.conn.Close()
+}
+
+// ArmorSSHSignature returns the armored encoding of an SSHSIG signature, as
+// accepted by `ssh-keygen -Y verify`.
+func ArmorSSHSignature(sig []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: SSHSignaturePEM, Bytes: sig})
+}
+
+// LoadSSHAllowedSigners returns an SSHSigSignerVerifier for each key in an
+// allowed_signers file, as described in ssh-keygen(1), that may sign for the
+// principal in the namespace. If principal is empty, all keys allowed for the
+// namespace are returned. The valid-after and valid-before options are honored
+// at verification time. Certificate authority entries are not supported and
+// are skipped.
+func LoadSSHAllowedSigners(data []byte, principal, namespace string) ([]*SSHSigSignerVerifier, error) {
+	verifiers := []*SSHSigSignerVerifier{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		principals, rest := splitAllowedSignersPrincipals(line)
+		publicKey, _, options, _, err := ssh.ParseAuthorizedKey([]byte(rest))
+		if err != nil {
+			return nil, fmt.Errorf("unable to load allowed signers: line %d: %w", lineNumber, err)
+		}
+
+		if principal != "" && !matchSSHPatternList(principal, principals) {
+			continue
+		}
+
+		sv, err := NewSSHSigVerifier(publicKey, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		allowed := true
+		for _, option := range options {
+			name, value, _ := strings.Cut(option, "=")
+			value = strings.Trim(value, `"`)
+
+			switch strings.ToLower(name) {
+			case "cert-authority":
+				allowed = false
+			case "namespaces":
+				allowed = allowed && matchSSHPatternList(namespace, value)
+			case "valid-after":
+				sv.validAfter, err = parseAllowedSignersTime(value)
+			case "valid-before":
+				sv.validBefore, err = parseAllowedSignersTime(value)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("unable to load allowed signers: line %d: %w", lineNumber, err)
+			}
+		}
+
+		if allowed {
+			verifiers = append(verifiers, sv)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to load allowed signers: %w", err)
+	}
+
+	return verifiers, nil
+}
+
+// splitAllowedSignersPrincipals splits the leading, optionally quoted,
+// principals field off an allowed_signers line. Fields are separated by any
+// whitespace.
+func splitAllowedSignersPrincipals(line string) (string, string) {
+	if strings.HasPrefix(line, `"`) {
+		if end := strings.Index(line[1:], `"`); end >= 0 {
+			return line[1 : end+1], strings.TrimSpace(line[end+2:])
+		}
+	}
+
+	end := strings.IndexFunc(line, unicode.IsSpace)
+	if end < 0 {
+		return line, ""
+	}
+	return line[:end], strings.TrimSpace(line[end:])
+}
+
+// parseAllowedSignersTime parses the YYYYMMDD[HHMM[SS]] timestamps used by the
+// valid-after and valid-before options. A trailing "Z" selects UTC, otherwise
+// the local time zone is used.
+func parseAllowedSignersTime(value string) (time.Time, error) {
+	location := time.Local
+	if strings.HasSuffix(value, "Z") {
+		location = time.UTC
+		value = strings.TrimSuffix(value, "Z")
+	}
+
+	layouts := map[int]string{
+		8:  "20060102",
+		12: "200601021504",
+		14: "20060102150405",
+	}
+	layout, ok := layouts[len(value)]
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid time %q", value)
+	}
+
+	return time.ParseInLocation(layout, value, location)
+}
+
+// matchSSHPatternList reports whether s matches the comma separated list of
+// patterns, following OpenSSH's rules: "*" and "?" are wildcards, and a match
+// against a pattern negated with "!" rejects s regardless of other patterns.
+func matchSSHPatternList(s, patterns string) bool {
+	matched := false
+	for _, pattern := range strings.Split(patterns, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if negated := strings.TrimPrefix(pattern, "!"); negated != pattern {
+			if matchSSHPattern(s, negated) {
+				return false
+			}
+			continue
+		}
+		if matchSSHPattern(s, pattern) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+func matchSSHPattern(s, pattern string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if matchSSHPattern(s[i:], pattern[1:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+		}
+		s = s[1:]
+		pattern = pattern[1:]
+	}
+	return len(s) == 0
+}
@@ -0,0 +1,333 @@
+package signerverifier
+
+import (
+	"context"
+	_ "embed"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+//go:embed test-data/ed25519-test-key-openssh.sig
+var ed25519SSHSignature []byte
+
+//go:embed test-data/ecdsa-test-key-openssh.sig
+var ecdsaSSHSignature []byte
+
+//go:embed test-data/rsa-test-key.sig
+var rsaSSHSignature []byte
+
+//go:embed test-data/allowed_signers
+var sshAllowedSigners []byte
+
+const sshSigTestNamespace = "test-namespace"
+
+func TestSSHSigSignerVerifier(t *testing.T) {
+	tests := map[string]struct {
+		keyBytes  []byte
+		signature []byte
+	}{
+		"ED25519 key": {
+			keyBytes:  ed25519OpenSSHPrivateKey,
+			signature: ed25519SSHSignature,
+		},
+		"ECDSA key": {
+			keyBytes:  ecdsaOpenSSHPrivateKey,
+			signature: ecdsaSSHSignature,
+		},
+		"RSA key": {
+			keyBytes:  rsaPrivateKey,
+			signature: rsaSSHSignature,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			signer, err := ssh.ParsePrivateKey(test.keyBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sv, err := NewSSHSigSignerVerifier(signer, sshSigTestNamespace)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			keyID, err := sv.KeyID()
+			assert.Nil(t, err)
+			expectedKeyID, err := dsse.SHA256KeyID(sv.Public())
+			assert.Nil(t, err)
+			assert.Equal(t, expectedKeyID, keyID)
+
+			message := []byte("test message")
+
+			// Signature created with `ssh-keygen -Y sign -n test-namespace`
+			assert.Nil(t, sv.Verify(context.Background(), message, test.signature))
+			assert.ErrorIs(t, sv.Verify(context.Background(), []byte("another message"), test.signature), ErrSignatureVerificationFailed)
+
+			signature, err := sv.Sign(context.Background(), message)
+			assert.Nil(t, err)
+			assert.Nil(t, sv.Verify(context.Background(), message, signature))
+			assert.Nil(t, sv.Verify(context.Background(), message, ArmorSSHSignature(signature)))
+
+			otherNamespace, err := NewSSHSigVerifier(signer.PublicKey(), "git")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.ErrorIs(t, otherNamespace.Verify(context.Background(), message, signature), ErrSignatureVerificationFailed)
+		})
+	}
+
+	t.Run("verifier cannot sign", func(t *testing.T) {
+		publicKey, _, _, _, err := ssh.ParseAuthorizedKey(ed25519OpenSSHPublicKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sv, err := NewSSHSigVerifier(publicKey, sshSigTestNamespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = sv.Sign(context.Background(), []byte("test message"))
+		assert.ErrorIs(t, err, ErrNotPrivateKey)
+		assert.Nil(t, sv.Verify(context.Background(), []byte("test message"), ed25519SSHSignature))
+		assert.ErrorIs(t, sv.Verify(context.Background(), []byte("test message"), ecdsaSSHSignature), ErrSignatureVerificationFailed)
+	})
+
+	t.Run("empty namespace", func(t *testing.T) {
+		signer, err := ssh.ParsePrivateKey(ed25519OpenSSHPrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewSSHSigSignerVerifier(signer, "")
+		assert.ErrorIs(t, err, ErrSSHSigNamespace)
+	})
+
+	t.Run("with DSSE envelope", func(t *testing.T) {
+		signer, err := ssh.ParsePrivateKey(ecdsaOpenSSHPrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sv, err := NewSSHSigSignerVerifier(signer, sshSigTestNamespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		es, err := dsse.NewEnvelopeSigner(sv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		env, err := es.SignPayload(context.Background(), "application/vnd.dsse+json", []byte("test message"))
+		assert.Nil(t, err)
+
+		verifiers, err := LoadSSHAllowedSigners(sshAllowedSigners, "bob@example.com", sshSigTestNamespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ev, err := dsse.NewEnvelopeVerifier(verifiers[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		acceptedKeys, err := ev.Verify(context.Background(), env)
+		assert.Nil(t, err)
+		assert.Equal(t, ssh.FingerprintSHA256(signer.PublicKey()), acceptedKeys[0].KeyID)
+	})
+}
+
+// startTestSSHAgent serves an in-memory ssh-agent holding the keys on a unix
+// socket and returns the socket path.
+func startTestSSHAgent(t *testing.T, keys ...[]byte) string {
+	t.Helper()
+
+	keyring := agent.NewKeyring()
+	for _, keyBytes := range keys {
+		key, err := ssh.ParseRawPrivateKey(keyBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := keyring.Add(agent.AddedKey{PrivateKey: key}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "agent.sock")
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() }) //nolint:errcheck
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn) //nolint:errcheck
+		}
+	}()
+
+	return socketPath
+}
+
+func TestNewSSHSigSignerVerifierFromAgent(t *testing.T) {
+	socketPath := startTestSSHAgent(t, ed25519OpenSSHPrivateKey, rsaPrivateKey)
+
+	rsaSigner, err := ssh.ParsePrivateKey(rsaPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("selected key", func(t *testing.T) {
+		sv, err := NewSSHSigSignerVerifierFromAgent(socketPath, rsaSigner.PublicKey(), sshSigTestNamespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sv.Close() //nolint:errcheck
+
+		keyID, err := sv.KeyID()
+		assert.Nil(t, err)
+		assert.Equal(t, ssh.FingerprintSHA256(rsaSigner.PublicKey()), keyID)
+
+		message := []byte("test message")
+		signature, err := sv.Sign(context.Background(), message)
+		assert.Nil(t, err)
+
+		verifier, err := NewSSHSigVerifier(rsaSigner.PublicKey(), sshSigTestNamespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, verifier.Verify(context.Background(), message, signature))
+	})
+
+	t.Run("SSH_AUTH_SOCK", func(t *testing.T) {
+		t.Setenv("SSH_AUTH_SOCK", socketPath)
+
+		sv, err := NewSSHSigSignerVerifierFromAgent("", nil, sshSigTestNamespace)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer sv.Close() //nolint:errcheck
+
+		signature, err := sv.Sign(context.Background(), []byte("test message"))
+		assert.Nil(t, err)
+		assert.Nil(t, sv.Verify(context.Background(), []byte("test message"), signature))
+	})
+
+	t.Run("key not in agent", func(t *testing.T) {
+		signer, err := ssh.ParsePrivateKey(ecdsaOpenSSHPrivateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = NewSSHSigSignerVerifierFromAgent(socketPath, signer.PublicKey(), sshSigTestNamespace)
+		assert.ErrorIs(t, err, ErrSSHAgentNoKey)
+	})
+}
+
+func TestLoadSSHAllowedSigners(t *testing.T) {
+	message := []byte("test message")
+
+	tests := map[string]struct {
+		principal         string
+		namespace         string
+		signature         []byte
+		expectedVerifiers int
+		expectVerified    bool
+	}{
+		"exact principal": {
+			principal:         "alice@example.com",
+			namespace:         sshSigTestNamespace,
+			signature:         ed25519SSHSignature,
+			expectedVerifiers: 1,
+			expectVerified:    true,
+		},
+		"quoted principal list": {
+			principal:         "bob@example.com",
+			namespace:         sshSigTestNamespace,
+			signature:         ecdsaSSHSignature,
+			expectedVerifiers: 1,
+			expectVerified:    true,
+		},
+		"wildcard principal": {
+			principal:         "build@ci.example.com",
+			namespace:         sshSigTestNamespace,
+			signature:         ecdsaSSHSignature,
+			expectedVerifiers: 1,
+			expectVerified:    true,
+		},
+		"negated principal": {
+			principal:         "untrusted@ci.example.com",
+			namespace:         sshSigTestNamespace,
+			expectedVerifiers: 0,
+		},
+		"namespace not allowed": {
+			principal:         "carol@example.com",
+			namespace:         sshSigTestNamespace,
+			expectedVerifiers: 0,
+		},
+		"expired key": {
+			principal:         "dave@example.com",
+			namespace:         sshSigTestNamespace,
+			signature:         rsaSSHSignature,
+			expectedVerifiers: 1,
+			expectVerified:    false,
+		},
+		"unknown principal": {
+			principal:         "mallory@example.com",
+			namespace:         sshSigTestNamespace,
+			expectedVerifiers: 0,
+		},
+		"any principal": {
+			namespace:         sshSigTestNamespace,
+			expectedVerifiers: 3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			verifiers, err := LoadSSHAllowedSigners(sshAllowedSigners, test.principal, test.namespace)
+			assert.Nil(t, err)
+			assert.Len(t, verifiers, test.expectedVerifiers)
+
+			if test.signature != nil {
+				err := verifiers[0].Verify(context.Background(), message, test.signature)
+				if test.expectVerified {
+					assert.Nil(t, err)
+				} else {
+					assert.ErrorIs(t, err, ErrSignatureVerificationFailed)
+				}
+			}
+		})
+	}
+
+	t.Run("invalid line", func(t *testing.T) {
+		_, err := LoadSSHAllowedSigners([]byte("alice@example.com ssh-ed25519 invalid"), "alice@example.com", sshSigTestNamespace)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("tab separated fields", func(t *testing.T) {
+		tabbed := strings.NewReplacer(
+			"alice@example.com ssh-ed25519 ", "alice@example.com\tssh-ed25519\t",
+			"dave@example.com valid-before", "dave@example.com\t valid-before",
+		).Replace(string(sshAllowedSigners))
+
+		verifiers, err := LoadSSHAllowedSigners([]byte(tabbed), "alice@example.com", sshSigTestNamespace)
+		assert.Nil(t, err)
+		if assert.Len(t, verifiers, 1) {
+			assert.Nil(t, verifiers[0].Verify(context.Background(), message, ed25519SSHSignature))
+		}
+
+		verifiers, err = LoadSSHAllowedSigners([]byte(tabbed), "dave@example.com", sshSigTestNamespace)
+		assert.Nil(t, err)
+		assert.Len(t, verifiers, 1)
+	})
+}
@@ -0,0 +1,313 @@
+package signerverifier
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/cryptobyte"
+	cryptobyte_asn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+var ErrCertificateTransparencyVerificationFailed = errors.New("unable to verify signed certificate timestamp")
+
+// oidSCTList is the X.509 extension holding embedded signed certificate
+// timestamps, see RFC 6962, section 3.3.
+var oidSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+// transparencyLogKeys indexes the public keys of transparency logs by their
+// hex encoded log ID, the SHA-256 digest of the PKIX encoded key. Rekor and
+// certificate transparency logs use the same log IDs.
+func transparencyLogKeys(publicKeys []crypto.PublicKey) (map[string]crypto.PublicKey, error) {
+	keys := make(map[string]crypto.PublicKey, len(publicKeys))
+	for _, publicKey := range publicKeys {
+		der, err := x509.MarshalPKIXPublicKey(publicKey)
+		if err != nil {
+			return nil, err
+		}
+		logID := sha256.Sum256(der)
+		keys[hex.EncodeToString(logID[:])] = publicKey
+	}
+	return keys, nil
+}
+
+// verifyWithPublicKey verifies a signature created by a transparency log.
+// ECDSA and RSA logs sign the SHA-256 digest of the message.
+func verifyWithPublicKey(publicKey crypto.PublicKey, message, sig []byte) error {
+	digest := sha256.Sum256(message)
+
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		if ecdsa.VerifyASN1(key, digest[:], sig) {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if ed25519.Verify(key, message, sig) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil {
+			return nil
+		}
+	default:
+		return ErrUnknownKeyType
+	}
+
+	return ErrSignatureVerificationFailed
+}
+
+// hashLeaf returns the RFC 6962 Merkle tree hash of a leaf.
+func hashLeaf(leaf []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write(leaf)
+	return h.Sum(nil)
+}
+
+// hashChildren returns the RFC 6962 Merkle tree hash of an interior node.
+func hashChildren(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+// verifyInclusionProof checks that the leaf hash at index is included in the
+// Merkle tree of the given size with the given root hash, following RFC 9162,
+// section 2.1.3.2.
+func verifyInclusionProof(index, treeSize int64, leafHash []byte, proof [][]byte, rootHash []byte) error {
+	if index < 0 || index >= treeSize {
+		return fmt.Errorf("%w: leaf index %d out of range for tree size %d", ErrTransparencyLogVerificationFailed, index, treeSize)
+	}
+
+	fn, sn := index, treeSize-1
+	r := leafHash
+	for _, p := range proof {
+		if sn == 0 {
+			return fmt.Errorf("%w: inclusion proof too long", ErrTransparencyLogVerificationFailed)
+		}
+		if fn&1 == 1 || fn == sn {
+			r = hashChildren(p, r)
+			for fn&1 == 0 && fn != 0 {
+				fn >>= 1
+				sn >>= 1
+			}
+		} else {
+			r = hashChildren(r, p)
+		}
+		fn >>= 1
+		sn >>= 1
+	}
+
+	if sn != 0 || !bytes.Equal(r, rootHash) {
+		return fmt.Errorf("%w: inclusion proof does not match root hash", ErrTransparencyLogVerificationFailed)
+	}
+	return nil
+}
+
+// verifyCheckpoint verifies a checkpoint in the signed note format used by
+// Rekor and checks that it commits to the tree size and root hash. Only a
+// signature by the log named in the checkpoint's origin line is accepted;
+// Rekor origins are "<name> - <tree ID>".
+func verifyCheckpoint(envelope string, treeSize int64, rootHash []byte, logKey crypto.PublicKey) error {
+	text, signatures, ok := strings.Cut(envelope, "\n\n")
+	if !ok {
+		return fmt.Errorf("%w: malformed checkpoint", ErrTransparencyLogVerificationFailed)
+	}
+	text += "\n"
+
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) < 3 {
+		return fmt.Errorf("%w: malformed checkpoint", ErrTransparencyLogVerificationFailed)
+	}
+	logName, _, _ := strings.Cut(lines[0], " - ")
+	if logName == "" {
+		return fmt.Errorf("%w: checkpoint has no origin", ErrTransparencyLogVerificationFailed)
+	}
+	size, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil || size != treeSize {
+		return fmt.Errorf("%w: checkpoint does not match tree size", ErrTransparencyLogVerificationFailed)
+	}
+	root, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || !bytes.Equal(root, rootHash) {
+		return fmt.Errorf("%w: checkpoint does not match root hash", ErrTransparencyLogVerificationFailed)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(logKey)
+	if err != nil {
+		return err
+	}
+	keyHash := sha256.Sum256(der)
+
+	for _, line := range strings.Split(strings.TrimSuffix(signatures, "\n"), "\n") {
+		// Signature lines are "— <name> <base64 key hint and signature>",
+		// where the key hint is the first four bytes of the log ID.
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "—" || fields[1] != logName {
+			continue
+		}
+		sig, err := base64.StdEncoding.DecodeString(fields[2])
+		if err != nil || len(sig) < 4 || !bytes.Equal(sig[:4], keyHash[:4]) {
+			continue
+		}
+		if verifyWithPublicKey(logKey, []byte(text), sig[4:]) == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: no valid checkpoint signature", ErrTransparencyLogVerificationFailed)
+}
+
+// verifyEmbeddedSCTs checks that the certificate includes at least one signed
+// certificate timestamp from a trusted log. The issuer of the certificate is
+// looked up in issuers, as the timestamp commits to the issuer's key.
+func verifyEmbeddedSCTs(certificate *x509.Certificate, issuers []*x509.Certificate, logKeys map[string]crypto.PublicKey) error {
+	var sctList []byte
+	for _, extension := range certificate.Extensions {
+		if extension.Id.Equal(oidSCTList) {
+			if _, err := asn1.Unmarshal(extension.Value, &sctList); err != nil {
+				return fmt.Errorf("%w: %w", ErrCertificateTransparencyVerificationFailed, err)
+			}
+		}
+	}
+	if sctList == nil {
+		return fmt.Errorf("%w: no embedded signed certificate timestamps", ErrCertificateTransparencyVerificationFailed)
+	}
+
+	var issuer *x509.Certificate
+	for _, candidate := range issuers {
+		if certificate.CheckSignatureFrom(candidate) == nil {
+			issuer = candidate
+			break
+		}
+	}
+	if issuer == nil {
+		return fmt.Errorf("%w: issuer not found", ErrCertificateTransparencyVerificationFailed)
+	}
+	issuerKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+
+	tbs, err := precertificateTBS(certificate.RawTBSCertificate)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCertificateTransparencyVerificationFailed, err)
+	}
+
+	list := cryptobyte.String(sctList)
+	var scts cryptobyte.String
+	if !list.ReadUint16LengthPrefixed(&scts) || !list.Empty() {
+		return fmt.Errorf("%w: malformed timestamp list", ErrCertificateTransparencyVerificationFailed)
+	}
+
+	for !scts.Empty() {
+		var sct cryptobyte.String
+		if !scts.ReadUint16LengthPrefixed(&sct) {
+			return fmt.Errorf("%w: malformed timestamp list", ErrCertificateTransparencyVerificationFailed)
+		}
+
+		var (
+			version                           uint8
+			logID                             []byte
+			timestamp                         uint64
+			extensions                        cryptobyte.String
+			hashAlgorithm, signatureAlgorithm uint8
+			sig                               cryptobyte.String
+		)
+		if !sct.ReadUint8(&version) || !sct.ReadBytes(&logID, sha256.Size) || !sct.ReadUint64(&timestamp) ||
+			!sct.ReadUint16LengthPrefixed(&extensions) || !sct.ReadUint8(&hashAlgorithm) || !sct.ReadUint8(&signatureAlgorithm) ||
+			!sct.ReadUint16LengthPrefixed(&sig) || !sct.Empty() {
+			return fmt.Errorf("%w: malformed timestamp", ErrCertificateTransparencyVerificationFailed)
+		}
+
+		// Only v1 timestamps signed over a SHA-256 digest are defined.
+		logKey, ok := logKeys[hex.EncodeToString(logID)]
+		if version != 0 || hashAlgorithm != 4 || !ok {
+			continue
+		}
+
+		// The digitally-signed struct for precertificate entries, see RFC
+		// 6962, section 3.2.
+		b := cryptobyte.NewBuilder(nil)
+		b.AddUint8(version)
+		b.AddUint8(0) // certificate_timestamp
+		b.AddUint64(timestamp)
+		b.AddUint16(1) // precert_entry
+		b.AddBytes(issuerKeyHash[:])
+		b.AddUint24LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(tbs) })
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) { b.AddBytes(extensions) })
+		signed, err := b.Bytes()
+		if err != nil {
+			return err
+		}
+
+		if verifyWithPublicKey(logKey, signed, sig) == nil {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: no valid timestamp from a trusted log", ErrCertificateTransparencyVerificationFailed)
+}
+
+// precertificateTBS returns the TBSCertificate as submitted to the log, i.e.
+// without the extension holding the signed certificate timestamps.
+func precertificateTBS(rawTBS []byte) ([]byte, error) {
+	input := cryptobyte.String(rawTBS)
+	var tbs cryptobyte.String
+	if !input.ReadASN1(&tbs, cryptobyte_asn1.SEQUENCE) {
+		return nil, errors.New("malformed TBSCertificate")
+	}
+
+	b := cryptobyte.NewBuilder(nil)
+	b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		for !tbs.Empty() {
+			var element cryptobyte.String
+			var tag cryptobyte_asn1.Tag
+			if !tbs.ReadAnyASN1Element(&element, &tag) {
+				b.SetError(errors.New("malformed TBSCertificate"))
+				return
+			}
+			if tag != cryptobyte_asn1.Tag(3).Constructed().ContextSpecific() {
+				b.AddBytes(element)
+				continue
+			}
+
+			var extensions cryptobyte.String
+			if !element.ReadASN1(&element, tag) || !element.ReadASN1(&extensions, cryptobyte_asn1.SEQUENCE) {
+				b.SetError(errors.New("malformed extensions"))
+				return
+			}
+			b.AddASN1(tag, func(b *cryptobyte.Builder) {
+				b.AddASN1(cryptobyte_asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					for !extensions.Empty() {
+						var extension, contents cryptobyte.String
+						var oid asn1.ObjectIdentifier
+						if !extensions.ReadASN1Element(&extension, cryptobyte_asn1.SEQUENCE) {
+							b.SetError(errors.New("malformed extension"))
+							return
+						}
+						contents = extension
+						if !contents.ReadASN1(&contents, cryptobyte_asn1.SEQUENCE) || !contents.ReadASN1ObjectIdentifier(&oid) {
+							b.SetError(errors.New("malformed extension"))
+							return
+						}
+						if !oid.Equal(oidSCTList) {
+							b.AddBytes(extension)
+						}
+					}
+				})
+			})
+		}
+	})
+
+	return b.Bytes()
+}
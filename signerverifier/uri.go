@@ -0,0 +1,196 @@
+package signerverifier
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+)
+
+const (
+	FileURIScheme          = "file"
+	EncryptedFileURIScheme = "file2"
+	EnvVarURIScheme        = "envvar"
+	SSHAgentURIScheme      = "ssh-agent"
+)
+
+var (
+	ErrUnknownURIScheme = errors.New("unknown signer URI scheme")
+	ErrKeyNotEncrypted  = errors.New("key is not encrypted")
+)
+
+// SignerURIOptions holds the options passed to a SignerURIHandler.
+type SignerURIOptions struct {
+	// PassphraseFunc returns the passphrase protecting the key referenced by
+	// the URI.
+	PassphraseFunc func(uri string) ([]byte, error)
+}
+
+// SignerURIOption configures SignerFromURI.
+type SignerURIOption func(*SignerURIOptions)
+
+// WithPassphraseFunc sets the function used to obtain the passphrase of
+// encrypted keys, e.g. by prompting the user.
+func WithPassphraseFunc(passphraseFunc func(uri string) ([]byte, error)) SignerURIOption {
+	return func(o *SignerURIOptions) {
+		o.PassphraseFunc = passphraseFunc
+	}
+}
+
+// SignerURIHandler creates a signer for a URI with the scheme it was
+// registered for. pubKey is the public key the signer must match and may be
+// nil for handlers that can derive it from the referenced key.
+type SignerURIHandler func(ctx context.Context, uri *url.URL, pubKey *SSLibKey, opts *SignerURIOptions) (dsse.SignerVerifier, error)
+
+var (
+	uriHandlersMu sync.RWMutex
+	uriHandlers   = map[string]SignerURIHandler{}
+)
+
+func init() {
+	RegisterSignerURIScheme(FileURIScheme, fileSignerFromURI)
+	RegisterSignerURIScheme(EncryptedFileURIScheme, fileSignerFromURI)
+	RegisterSignerURIScheme(EnvVarURIScheme, envVarSignerFromURI)
+	RegisterSignerURIScheme(SSHAgentURIScheme, sshAgentSignerFromURI)
+}
+
+// RegisterSignerURIScheme registers the handler used by SignerFromURI for
+// URIs with the given scheme, replacing any handler already registered for
+// it. This allows packages to add support for key management systems.
+func RegisterSignerURIScheme(scheme string, handler SignerURIHandler) {
+	uriHandlersMu.Lock()
+	defer uriHandlersMu.Unlock()
+
+	uriHandlers[scheme] = handler
+}
+
+// SignerFromURI creates a signer for the private key referenced by uri, in the
+// style of python-securesystemslib's Signer.from_priv_key_uri. The signer
+// uses the scheme and keyid of pubKey, and the referenced key must match it.
+// The built-in schemes are:
+//
+//	file:<path>        an unencrypted private key file in any format LoadKey or LoadSSLibKeyStrict accepts
+//	file2:<path>       an encrypted private key file, see LoadKeyWithPassphrase and WithPassphraseFunc; unencrypted files are rejected
+//	envvar:<name>      an environment variable holding the private portion of pubKey, as in keyval.private
+//	ssh-agent:[<path>] the key matching pubKey in the ssh-agent listening on path, or SSH_AUTH_SOCK
+//
+// pubKey may be nil for file: and file2: URIs, in which case the key read from
+// the file is used as is.
+func SignerFromURI(ctx context.Context, uri string, pubKey *SSLibKey, opts ...SignerURIOption) (dsse.SignerVerifier, error) {
+	parsedURI, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse signer URI: %w", err)
+	}
+
+	uriHandlersMu.RLock()
+	handler, ok := uriHandlers[parsedURI.Scheme]
+	uriHandlersMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownURIScheme, parsedURI.Scheme)
+	}
+
+	options := &SignerURIOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return handler(ctx, parsedURI, pubKey, options)
+}
+
+// uriPath returns the path of file style URIs, which may be relative
+// ("file:keys/key") or absolute ("file:/keys/key" or "file:///keys/key").
+func uriPath(uri *url.URL) string {
+	if uri.Opaque != "" {
+		return uri.Opaque
+	}
+	return uri.Path
+}
+
+func fileSignerFromURI(_ context.Context, uri *url.URL, pubKey *SSLibKey, opts *SignerURIOptions) (dsse.SignerVerifier, error) {
+	path := uriPath(uri)
+	if path == "" {
+		return nil, fmt.Errorf("unable to create signer for %q: missing path", uri)
+	}
+
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+	}
+
+	var key *SSLibKey
+	if uri.Scheme == EncryptedFileURIScheme {
+		if !isEncryptedKey(contents) {
+			return nil, fmt.Errorf("unable to create signer for %q: %w", uri, ErrKeyNotEncrypted)
+		}
+		if opts.PassphraseFunc == nil {
+			return nil, fmt.Errorf("unable to create signer for %q: %w", uri, ErrPassphraseRequired)
+		}
+		passphrase, err := opts.PassphraseFunc(uri.String())
+		if err != nil {
+			return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+		}
+		key, err = LoadKeyWithPassphrase(contents, passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+		}
+	} else {
+		if json.Valid(contents) {
+			key, err = LoadSSLibKeyStrict(contents)
+		} else {
+			key, err = LoadKey(contents)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+		}
+	}
+
+	if len(key.KeyVal.Private) == 0 {
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, ErrNotPrivateKey)
+	}
+	if pubKey == nil {
+		return NewSignerVerifierFromSSLibKey(key)
+	}
+
+	public, err := key.publicCryptoKey()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+	}
+	expectedPublic, err := pubKey.publicCryptoKey()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+	}
+	if !expectedPublic.(interface{ Equal(crypto.PublicKey) bool }).Equal(public) {
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, ErrKeyPairMismatch)
+	}
+
+	signingKey := *pubKey
+	signingKey.KeyVal.Private = key.KeyVal.Private
+	return NewSignerVerifierFromSSLibKey(&signingKey)
+}
+
+func envVarSignerFromURI(_ context.Context, uri *url.URL, pubKey *SSLibKey, _ *SignerURIOptions) (dsse.SignerVerifier, error) {
+	if pubKey == nil {
+		return nil, fmt.Errorf("unable to create signer for %q: %w: public key required", uri, ErrInvalidKey)
+	}
+
+	name := uriPath(uri)
+	private, ok := os.LookupEnv(name)
+	if !ok || private == "" {
+		return nil, fmt.Errorf("unable to create signer for %q: environment variable %s is not set", uri, name)
+	}
+
+	signingKey := *pubKey
+	signingKey.KeyVal.Private = private
+	if err := signingKey.validateKeyMaterial(); err != nil {
+		return nil, fmt.Errorf("unable to create signer for %q: %w", uri, err)
+	}
+
+	return NewSignerVerifierFromSSLibKey(&signingKey)
+}
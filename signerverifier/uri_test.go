@@ -0,0 +1,252 @@
+package signerverifier
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignerFromURI(t *testing.T) {
+	message := []byte("test message")
+
+	loadKey := func(t *testing.T, keyBytes []byte) *SSLibKey {
+		t.Helper()
+		key, err := LoadKey(keyBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	ed25519Key := loadKey(t, ed25519PrivateKey)
+	ecdsaKey := loadKey(t, ecdsaPrivateKey)
+
+	absolutePath, err := filepath.Abs(filepath.Join("test-data", "ecdsa-test-key-pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	passphraseFunc := WithPassphraseFunc(func(string) ([]byte, error) {
+		return []byte(testPassphrase), nil
+	})
+
+	t.Setenv("TEST_ED25519_PRIVATE_KEY", ed25519Key.KeyVal.Private)
+	t.Setenv("TEST_ECDSA_PRIVATE_KEY", ecdsaKey.KeyVal.Private)
+
+	socketPath := startTestSSHAgent(t, ed25519OpenSSHPrivateKey, ecdsaPrivateKey, rsaPrivateKey)
+
+	rsaPKCS1v15Key := loadKey(t, rsaPublicKey)
+	rsaPKCS1v15Key.Scheme = RSAPKCS1v15SHA256KeyScheme
+
+	tests := map[string]struct {
+		uri    string
+		pubKey *SSLibKey
+		opts   []SignerURIOption
+	}{
+		"file with relative path": {
+			uri:    "file:test-data/ecdsa-test-key-pem",
+			pubKey: loadKey(t, ecdsaPublicKey),
+		},
+		"file with absolute path": {
+			uri:    "file://" + absolutePath,
+			pubKey: loadKey(t, ecdsaPublicKey),
+		},
+		"file in securesystemslib format": {
+			uri:    "file:test-data/ed25519-test-key",
+			pubKey: loadKey(t, ed25519PublicKey),
+		},
+		"file without public key": {
+			uri: "file:test-data/rsa-test-key",
+		},
+		"encrypted file": {
+			uri:    "file2:test-data/ecdsa-test-key-pem-encrypted",
+			pubKey: loadKey(t, ecdsaPublicKey),
+			opts:   []SignerURIOption{passphraseFunc},
+		},
+		"encrypted securesystemslib file": {
+			uri:  "file2:test-data/ed25519-test-key-pem-encrypted.json",
+			opts: []SignerURIOption{passphraseFunc},
+		},
+		"encrypted OpenSSH file": {
+			uri:  "file2:test-data/ed25519-test-key-openssh-encrypted",
+			opts: []SignerURIOption{passphraseFunc},
+		},
+		"ED25519 environment variable": {
+			uri:    "envvar:TEST_ED25519_PRIVATE_KEY",
+			pubKey: loadKey(t, ed25519PublicKey),
+		},
+		"ECDSA environment variable": {
+			uri:    "envvar:TEST_ECDSA_PRIVATE_KEY",
+			pubKey: loadKey(t, ecdsaPublicKey),
+		},
+		"ED25519 ssh-agent key": {
+			uri:    "ssh-agent:" + socketPath,
+			pubKey: loadKey(t, ed25519OpenSSHPublicKey),
+		},
+		"ECDSA ssh-agent key": {
+			uri:    "ssh-agent:" + socketPath,
+			pubKey: loadKey(t, ecdsaPublicKey),
+		},
+		"RSA PKCS#1 v1.5 ssh-agent key": {
+			uri:    "ssh-agent:" + socketPath,
+			pubKey: rsaPKCS1v15Key,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sv, err := SignerFromURI(context.Background(), test.uri, test.pubKey, test.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.pubKey != nil {
+				keyID, err := sv.KeyID()
+				assert.Nil(t, err)
+				assert.Equal(t, test.pubKey.KeyID, keyID)
+			}
+
+			es, err := dsse.NewEnvelopeSigner(sv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			env, err := es.SignPayload(context.Background(), "application/vnd.dsse+json", message)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Signatures verify with the public key alone.
+			verifier := dsse.Verifier(sv)
+			if test.pubKey != nil {
+				verifier, err = NewSignerVerifierFromSSLibKey(test.pubKey.PublicOnly())
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			ev, err := dsse.NewEnvelopeVerifier(verifier)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = ev.Verify(context.Background(), env)
+			assert.Nil(t, err)
+		})
+	}
+
+	t.Run("SSH_AUTH_SOCK", func(t *testing.T) {
+		t.Setenv("SSH_AUTH_SOCK", socketPath)
+
+		sv, err := SignerFromURI(context.Background(), "ssh-agent:", loadKey(t, ecdsaPublicKey))
+		if err != nil {
+			t.Fatal(err)
+		}
+		sig, err := sv.Sign(context.Background(), message)
+		assert.Nil(t, err)
+		assert.Nil(t, sv.Verify(context.Background(), message, sig))
+	})
+
+	errorTests := map[string]struct {
+		uri         string
+		pubKey      *SSLibKey
+		opts        []SignerURIOption
+		expectedErr error
+	}{
+		"unknown scheme": {
+			uri:         "gcpkms:projects/p/locations/l/keyRings/r/cryptoKeys/k",
+			pubKey:      loadKey(t, ecdsaPublicKey),
+			expectedErr: ErrUnknownURIScheme,
+		},
+		"public key file": {
+			uri:         "file:test-data/ecdsa-test-key-pem.pub",
+			expectedErr: ErrNotPrivateKey,
+		},
+		"file does not match public key": {
+			uri:         "file:test-data/ecdsa-test-key-pem",
+			pubKey:      loadKey(t, ecdsaP384PublicKey),
+			expectedErr: ErrKeyPairMismatch,
+		},
+		"encrypted file without passphrase": {
+			uri:         "file2:test-data/ecdsa-test-key-pem-encrypted",
+			expectedErr: ErrPassphraseRequired,
+		},
+		"encrypted file with file scheme": {
+			uri:         "file:test-data/ecdsa-test-key-pem-encrypted",
+			expectedErr: ErrPassphraseRequired,
+		},
+		"unencrypted file with file2 scheme": {
+			uri: "file2:test-data/ecdsa-test-key-pem",
+			opts: []SignerURIOption{WithPassphraseFunc(func(string) ([]byte, error) {
+				return []byte("passphrase"), nil
+			})},
+			expectedErr: ErrKeyNotEncrypted,
+		},
+		"encrypted file with wrong passphrase": {
+			uri: "file2:test-data/ecdsa-test-key-pem-encrypted",
+			opts: []SignerURIOption{WithPassphraseFunc(func(string) ([]byte, error) {
+				return []byte("wrong passphrase"), nil
+			})},
+			expectedErr: ErrIncorrectPassphrase,
+		},
+		"environment variable does not match public key": {
+			uri:         "envvar:TEST_ED25519_PRIVATE_KEY",
+			pubKey:      loadKey(t, ed25519OpenSSHPublicKey),
+			expectedErr: ErrKeyPairMismatch,
+		},
+		"environment variable without public key": {
+			uri:         "envvar:TEST_ED25519_PRIVATE_KEY",
+			expectedErr: ErrInvalidKey,
+		},
+		"RSA-PSS ssh-agent key": {
+			uri:         "ssh-agent:" + socketPath,
+			pubKey:      loadKey(t, rsaPublicKey),
+			expectedErr: ErrUnknownScheme,
+		},
+		"key not in ssh-agent": {
+			uri:         "ssh-agent:" + socketPath,
+			pubKey:      loadKey(t, ecdsaP384PublicKey),
+			expectedErr: ErrSSHAgentNoKey,
+		},
+	}
+
+	for name, test := range errorTests {
+		t.Run(name, func(t *testing.T) {
+			_, err := SignerFromURI(context.Background(), test.uri, test.pubKey, test.opts...)
+			assert.ErrorIs(t, err, test.expectedErr)
+		})
+	}
+
+	t.Run("unset environment variable", func(t *testing.T) {
+		_, err := SignerFromURI(context.Background(), "envvar:TEST_UNSET_PRIVATE_KEY", ed25519Key)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("passphrase error", func(t *testing.T) {
+		errNoTerminal := errors.New("no terminal")
+		_, err := SignerFromURI(context.Background(), "file2:test-data/ecdsa-test-key-pem-encrypted", nil, WithPassphraseFunc(func(string) ([]byte, error) {
+			return nil, errNoTerminal
+		}))
+		assert.ErrorIs(t, err, errNoTerminal)
+	})
+
+	t.Run("custom scheme", func(t *testing.T) {
+		t.Cleanup(func() {
+			uriHandlersMu.Lock()
+			defer uriHandlersMu.Unlock()
+
+			delete(uriHandlers, "test-kms")
+		})
+		RegisterSignerURIScheme("test-kms", func(_ context.Context, uri *url.URL, pubKey *SSLibKey, _ *SignerURIOptions) (dsse.SignerVerifier, error) {
+			return &customSignerVerifier{keyID: uri.Opaque}, nil
+		})
+
+		sv, err := SignerFromURI(context.Background(), "test-kms:key-1", nil)
+		assert.Nil(t, err)
+		keyID, err := sv.KeyID()
+		assert.Nil(t, err)
+		assert.Equal(t, "key-1", keyID)
+	})
+}
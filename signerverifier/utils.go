@@ -1,15 +1,12 @@
 package signerverifier
 
 import (
-	"crypto/sha256"
 	"crypto/x509"
-	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"hash"
-
-	"github.com/secure-systems-lab/go-securesystemslib/cjson"
+	"io"
 )
 
 /*
@@ -25,7 +22,8 @@ var (
 
 // LoadKeyFromSSLibBytes returns a pointer to a Key instance created from the
 // contents of the bytes. The key contents are expected to be in the custom
-// securesystemslib format.
+// securesystemslib format. A keyid included in the contents is not checked
+// against the key, use LoadSSLibKeyStrict for that.
 //
 // Deprecated: use LoadKey() for all key types, RSA is no longer the only key
 // that uses PEM serialization.
@@ -45,21 +43,10 @@ func LoadKeyFromSSLibBytes(contents []byte) (*SSLibKey, error) {
 	return key, nil
 }
 
+// calculateKeyID returns the SHA-256 keyid of the key, which is what
+// securesystemslib assigns to new keys.
 func calculateKeyID(k *SSLibKey) (string, error) {
-	key := map[string]any{
-		"keytype":               k.KeyType,
-		"scheme":                k.Scheme,
-		"keyid_hash_algorithms": k.KeyIDHashAlgorithms,
-		"keyval": map[string]string{
-			"public": k.KeyVal.Public,
-		},
-	}
-	canonical, err := cjson.EncodeCanonical(key)
-	if err != nil {
-		return "", err
-	}
-	digest := sha256.Sum256(canonical)
-	return hex.EncodeToString(digest[:]), nil
+	return k.CalculateKeyID(KeyIDHashAlgorithmSHA256)
 }
 
 /*
@@ -140,3 +127,12 @@ func hashBeforeSigning(data []byte, h hash.Hash) []byte {
 	h.Write(data)
 	return h.Sum(nil)
 }
+
+// hashReaderBeforeSigning is the counterpart of hashBeforeSigning for messages
+// read incrementally.
+func hashReaderBeforeSigning(r io.Reader, h hash.Hash) ([]byte, error) {
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
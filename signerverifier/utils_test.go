@@ -1,11 +1,16 @@
 package signerverifier
 
 import (
+	"bytes"
 	"encoding/hex"
+	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,3 +54,72 @@ func TestLoadKeyFromSSLibBytes(t *testing.T) {
 		assert.Equal(t, RSAKeyType, key.KeyType)
 	})
 }
+
+// testStreamSignerVerifier checks that signatures created from a buffer and
+// from a stream are interchangeable, and that detached DSSE envelopes work.
+func testStreamSignerVerifier(t *testing.T, sv dsse.SignerVerifier) {
+	t.Helper()
+
+	streamSV, ok := sv.(interface {
+		dsse.StreamSigner
+		dsse.StreamVerifier
+	})
+	if !ok {
+		t.Fatalf("%T does not implement dsse.StreamSigner and dsse.StreamVerifier", sv)
+	}
+
+	message := []byte("test message")
+
+	signature, err := streamSV.SignStream(t.Context(), bytes.NewReader(message))
+	assert.Nil(t, err)
+	assert.Nil(t, sv.Verify(t.Context(), message, signature))
+
+	signature, err = sv.Sign(t.Context(), message)
+	assert.Nil(t, err)
+	assert.Nil(t, streamSV.VerifyStream(t.Context(), bytes.NewReader(message), signature))
+	assert.ErrorIs(t, streamSV.VerifyStream(t.Context(), strings.NewReader("other message"), signature), ErrSignatureVerificationFailed)
+
+	es, err := dsse.NewEnvelopeSigner(sv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := es.SignDetached(t.Context(), "application/octet-stream", bytes.NewReader(message), int64(len(message)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err := dsse.NewEnvelopeVerifier(sv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acceptedKeys, err := ev.VerifyDetached(t.Context(), env, bytes.NewReader(message), int64(len(message)))
+	assert.Nil(t, err)
+	assert.Len(t, acceptedKeys, 1)
+}
+
+// benchmarkSignDetached signs zeroed payloads of up to 1 GiB as detached DSSE
+// envelopes, to show that allocations do not depend on the payload size.
+func benchmarkSignDetached(b *testing.B, signer dsse.Signer) {
+	es, err := dsse.NewEnvelopeSigner(signer)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, size := range []int64{1 << 20, 1 << 30} {
+		b.Run(strconv.FormatInt(size>>20, 10)+"MiB", func(b *testing.B) {
+			b.SetBytes(size)
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := es.SignDetached(b.Context(), "application/octet-stream", io.LimitReader(zeroReader{}, size), size); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
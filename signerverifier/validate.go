@@ -0,0 +1,233 @@
+package signerverifier
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrMalformedKey    = errors.New("malformed key material")
+	ErrKeyPairMismatch = errors.New("private key does not match public key")
+)
+
+// KeyValidationError is returned by SSLibKey.Validate and LoadSSLibKeyStrict.
+// Field names the offending member of the securesystemslib JSON format, and
+// Err is one of ErrUnknownKeyType, ErrUnknownScheme, ErrSchemeMismatch,
+// ErrInvalidKey, ErrMalformedKey, ErrKeyPairMismatch, ErrKeyIDMismatch,
+// ErrUnknownKeyIDHashAlgorithm or ErrInvalidCertificate, so that it can be
+// inspected with errors.Is.
+type KeyValidationError struct {
+	Field string
+	Err   error
+}
+
+func (e *KeyValidationError) Error() string {
+	return fmt.Sprintf("invalid key %s: %s", e.Field, e.Err)
+}
+
+func (e *KeyValidationError) Unwrap() error {
+	return e.Err
+}
+
+// LoadSSLibKeyStrict returns an SSLibKey object when provided a key in the
+// securesystemslib JSON format. Unlike LoadKeyFromSSLibBytes, no other
+// encodings are accepted, and the key is checked with Validate. If the
+// contents do not include a keyid, the SHA-256 keyid is assigned.
+func LoadSSLibKeyStrict(contents []byte) (*SSLibKey, error) {
+	key := &SSLibKey{}
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	if err := decoder.Decode(key); err != nil {
+		return nil, fmt.Errorf("unable to load key: %w", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("unable to load key: unexpected data after key")
+	}
+
+	if len(key.KeyID) == 0 {
+		keyID, err := calculateKeyID(key)
+		if err != nil {
+			return nil, &KeyValidationError{Field: "keyid_hash_algorithms", Err: err}
+		}
+		key.KeyID = keyID
+	}
+
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Validate checks that the key is consistent: a signerverifier must be
+// registered for its keytype and scheme, the scheme must match the key
+// material, the public and private portions must be well formed and belong
+// together, and the keyid must match the key for one of its
+// keyid_hash_algorithms. The key material of custom key types registered with
+// RegisterSignerVerifierFactory is not inspected. For certificate-backed keys
+// without a public portion, only the encoding of the certificate is checked;
+// the chain is validated by NewCertificateVerifierFromSSLibKey. Keyless
+// "sigstore-oidc" keys must name an identity and issuer. A *KeyValidationError
+// is returned for the first problem found.
+func (k *SSLibKey) Validate() error {
+	if k.KeyType == SigstoreKeyType {
+		return k.validateSigstoreKey()
+	}
+
+	factoriesMu.RLock()
+	_, registered := factories[keyTypeAndScheme{keyType: k.KeyType, scheme: k.Scheme}]
+	knownKeyType := false
+	for registeredKey := range factories {
+		if registeredKey.keyType == k.KeyType {
+			knownKeyType = true
+			break
+		}
+	}
+	factoriesMu.RUnlock()
+
+	if !knownKeyType {
+		return &KeyValidationError{Field: "keytype", Err: fmt.Errorf("%w: %q", ErrUnknownKeyType, k.KeyType)}
+	}
+	if !registered {
+		return &KeyValidationError{Field: "scheme", Err: fmt.Errorf("%w: %q for keytype %q", ErrUnknownScheme, k.Scheme, k.KeyType)}
+	}
+
+	if len(k.KeyVal.Public) == 0 && len(k.KeyVal.Certificate) > 0 {
+		// The keyid of certificate-backed keys is not derived from the key,
+		// which is only known once the certificate has been validated, see
+		// NewCertificateVerifierFromSSLibKey.
+		if _, err := parseCertificates([]byte(k.KeyVal.Certificate)); err != nil {
+			return &KeyValidationError{Field: "keyval.certificate", Err: err}
+		}
+		if len(k.KeyID) == 0 {
+			return &KeyValidationError{Field: "keyid", Err: fmt.Errorf("%w: missing keyid", ErrKeyIDMismatch)}
+		}
+		return nil
+	}
+
+	if len(k.KeyVal.Public) == 0 {
+		return &KeyValidationError{Field: "keyval.public", Err: ErrInvalidKey}
+	}
+
+	if err := k.validateKeyMaterial(); err != nil {
+		return err
+	}
+
+	if len(k.KeyID) == 0 {
+		return &KeyValidationError{Field: "keyid", Err: fmt.Errorf("%w: missing keyid", ErrKeyIDMismatch)}
+	}
+	if err := k.VerifyKeyID(); err != nil {
+		field := "keyid"
+		if errors.Is(err, ErrUnknownKeyIDHashAlgorithm) {
+			field = "keyid_hash_algorithms"
+		}
+		return &KeyValidationError{Field: field, Err: err}
+	}
+
+	return nil
+}
+
+// validateSigstoreKey checks keyless keys, which have no key material and
+// whose keyid is assigned by the metadata author.
+func (k *SSLibKey) validateSigstoreKey() error {
+	if k.Scheme != SigstoreKeyScheme {
+		return &KeyValidationError{Field: "scheme", Err: fmt.Errorf("%w: %q for keytype %q", ErrUnknownScheme, k.Scheme, k.KeyType)}
+	}
+	if len(k.KeyVal.Identity) == 0 {
+		return &KeyValidationError{Field: "keyval.identity", Err: ErrInvalidKey}
+	}
+	if len(k.KeyVal.Issuer) == 0 {
+		return &KeyValidationError{Field: "keyval.issuer", Err: ErrInvalidKey}
+	}
+	if len(k.KeyID) == 0 {
+		return &KeyValidationError{Field: "keyid", Err: fmt.Errorf("%w: missing keyid", ErrKeyIDMismatch)}
+	}
+	return nil
+}
+
+// validateKeyMaterial checks the public and private portions of built-in key
+// types.
+func (k *SSLibKey) validateKeyMaterial() error {
+	var public crypto.PublicKey
+	switch {
+	case k.KeyType == ED25519KeyType:
+		publicBytes, err := hex.DecodeString(k.KeyVal.Public)
+		if err != nil || len(publicBytes) != ed25519.PublicKeySize {
+			return &KeyValidationError{Field: "keyval.public", Err: fmt.Errorf("%w: expected %d hex encoded bytes", ErrMalformedKey, ed25519.PublicKeySize)}
+		}
+		public = ed25519.PublicKey(publicBytes)
+
+	case k.KeyType == ECDSAKeyType || k.KeyType == RSAKeyType || ecdsaSchemes[k.KeyType].curve != nil:
+		_, parsedKey, err := decodeAndParsePEM([]byte(k.KeyVal.Public))
+		if err != nil {
+			return &KeyValidationError{Field: "keyval.public", Err: fmt.Errorf("%w: %w", ErrMalformedKey, err)}
+		}
+
+		switch p := parsedKey.(type) {
+		case *ecdsa.PublicKey:
+			if k.KeyType == RSAKeyType {
+				return &KeyValidationError{Field: "keyval.public", Err: fmt.Errorf("%w: expected an RSA public key", ErrMalformedKey)}
+			}
+			if ecdsaSchemes[k.Scheme].curve != p.Curve {
+				return &KeyValidationError{Field: "scheme", Err: fmt.Errorf("%w: %q for curve %s", ErrSchemeMismatch, k.Scheme, p.Curve.Params().Name)}
+			}
+		case *rsa.PublicKey:
+			if k.KeyType != RSAKeyType {
+				return &KeyValidationError{Field: "keyval.public", Err: fmt.Errorf("%w: expected an ECDSA public key", ErrMalformedKey)}
+			}
+		default:
+			// Private keys are parsed as well, but must never be stored in
+			// the public portion.
+			return &KeyValidationError{Field: "keyval.public", Err: fmt.Errorf("%w: not a public key", ErrMalformedKey)}
+		}
+		public = parsedKey
+
+	case isMLDSAKeyType(k.KeyType):
+		parsedKey, err := k.parseMLDSAPublicKey()
+		if err != nil {
+			return err
+		}
+		public = parsedKey
+
+	default:
+		return nil
+	}
+
+	if len(k.KeyVal.Private) == 0 {
+		return nil
+	}
+
+	private, err := k.privateCryptoKey()
+	if err != nil {
+		return &KeyValidationError{Field: "keyval.private", Err: fmt.Errorf("%w: %w", ErrMalformedKey, err)}
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return &KeyValidationError{Field: "keyval.private", Err: fmt.Errorf("%w: not a private key", ErrMalformedKey)}
+	}
+
+	switch p := private.(type) {
+	case ed25519.PrivateKey:
+		// The public half embedded in 64 byte keys must match the seed.
+		if !p.Equal(ed25519.NewKeyFromSeed(p.Seed())) {
+			return &KeyValidationError{Field: "keyval.private", Err: ErrKeyPairMismatch}
+		}
+	case *rsa.PrivateKey:
+		if err := p.Validate(); err != nil {
+			return &KeyValidationError{Field: "keyval.private", Err: fmt.Errorf("%w: %w", ErrMalformedKey, err)}
+		}
+	}
+
+	if !public.(interface{ Equal(crypto.PublicKey) bool }).Equal(signer.Public()) {
+		return &KeyValidationError{Field: "keyval.private", Err: ErrKeyPairMismatch}
+	}
+
+	return nil
+}
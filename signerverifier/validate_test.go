@@ -0,0 +1,267 @@
+package signerverifier
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/secure-systems-lab/go-securesystemslib/dsse"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	for name, keyBytes := range map[string][]byte{
+		"RSA private key":       rsaPrivateKey,
+		"RSA public key":        rsaPublicKey,
+		"ED25519 private key":   ed25519PrivateKey,
+		"ED25519 public key":    ed25519PublicKey,
+		"ECDSA private key":     ecdsaPrivateKey,
+		"ECDSA public key":      ecdsaPublicKey,
+		"ECDSA P-521 private":   ecdsaP521PrivateKey,
+		"OpenSSH ED25519 key":   ed25519OpenSSHPrivateKey,
+		"OpenSSH ECDSA pub key": ecdsaOpenSSHPublicKey,
+	} {
+		t.Run(name, func(t *testing.T) {
+			key, err := LoadKey(keyBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Nil(t, key.Validate())
+		})
+	}
+
+	loadKey := func(t *testing.T, keyBytes []byte) *SSLibKey {
+		t.Helper()
+		key, err := LoadKey(keyBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+
+	otherECDSAKey := loadKey(t, ecdsaP384PrivateKey)
+	otherED25519Key := loadKey(t, ed25519OpenSSHPrivateKey)
+
+	tests := map[string]struct {
+		keyBytes      []byte
+		modify        func(key *SSLibKey)
+		expectedField string
+		expectedErr   error
+	}{
+		"unknown keytype": {
+			keyBytes:      ed25519PublicKey,
+			modify:        func(key *SSLibKey) { key.KeyType = "ed448" },
+			expectedField: "keytype",
+			expectedErr:   ErrUnknownKeyType,
+		},
+		"unknown scheme": {
+			keyBytes:      rsaPublicKey,
+			modify:        func(key *SSLibKey) { key.Scheme = "rsassa-pss-md5" },
+			expectedField: "scheme",
+			expectedErr:   ErrUnknownScheme,
+		},
+		"scheme does not match curve": {
+			keyBytes: ecdsaPublicKey,
+			modify: func(key *SSLibKey) {
+				key.Scheme = ECDSAP384KeyScheme
+				key.KeyID, _ = calculateKeyID(key)
+			},
+			expectedField: "scheme",
+			expectedErr:   ErrSchemeMismatch,
+		},
+		"ECDSA key with RSA keytype": {
+			keyBytes: ecdsaPublicKey,
+			modify: func(key *SSLibKey) {
+				key.KeyType = RSAKeyType
+				key.Scheme = RSAKeyScheme
+			},
+			expectedField: "keyval.public",
+			expectedErr:   ErrMalformedKey,
+		},
+		"missing public key": {
+			keyBytes:      ed25519PublicKey,
+			modify:        func(key *SSLibKey) { key.KeyVal.Public = "" },
+			expectedField: "keyval.public",
+			expectedErr:   ErrInvalidKey,
+		},
+		"malformed hex": {
+			keyBytes:      ed25519PublicKey,
+			modify:        func(key *SSLibKey) { key.KeyVal.Public = "not hex" },
+			expectedField: "keyval.public",
+			expectedErr:   ErrMalformedKey,
+		},
+		"truncated ED25519 public key": {
+			keyBytes:      ed25519PublicKey,
+			modify:        func(key *SSLibKey) { key.KeyVal.Public = key.KeyVal.Public[:32] },
+			expectedField: "keyval.public",
+			expectedErr:   ErrMalformedKey,
+		},
+		"malformed PEM": {
+			keyBytes: rsaPublicKey,
+			modify: func(key *SSLibKey) {
+				key.KeyVal.Public = "-----BEGIN PUBLIC KEY-----\ninvalid\n-----END PUBLIC KEY-----"
+			},
+			expectedField: "keyval.public",
+			expectedErr:   ErrMalformedKey,
+		},
+		"private key in public portion": {
+			keyBytes:      ecdsaPrivateKey,
+			modify:        func(key *SSLibKey) { key.KeyVal.Public = key.KeyVal.Private },
+			expectedField: "keyval.public",
+			expectedErr:   ErrMalformedKey,
+		},
+		"malformed private key": {
+			keyBytes:      ed25519PrivateKey,
+			modify:        func(key *SSLibKey) { key.KeyVal.Private = "abcd" },
+			expectedField: "keyval.private",
+			expectedErr:   ErrMalformedKey,
+		},
+		"ECDSA private key does not match public key": {
+			keyBytes:      ecdsaPrivateKey,
+			modify:        func(key *SSLibKey) { key.KeyVal.Private = otherECDSAKey.KeyVal.Private },
+			expectedField: "keyval.private",
+			expectedErr:   ErrKeyPairMismatch,
+		},
+		"ED25519 private key does not match public key": {
+			keyBytes:      ed25519PrivateKey,
+			modify:        func(key *SSLibKey) { key.KeyVal.Private = otherED25519Key.KeyVal.Private },
+			expectedField: "keyval.private",
+			expectedErr:   ErrKeyPairMismatch,
+		},
+		"ED25519 private key with foreign public half": {
+			keyBytes: ed25519PrivateKey,
+			modify: func(key *SSLibKey) {
+				key.KeyVal.Private = key.KeyVal.Private[:64] + otherED25519Key.KeyVal.Public
+			},
+			expectedField: "keyval.private",
+			expectedErr:   ErrKeyPairMismatch,
+		},
+		"tampered keyid": {
+			keyBytes:      rsaPublicKey,
+			modify:        func(key *SSLibKey) { key.KeyID = strings.Repeat("0", 64) },
+			expectedField: "keyid",
+			expectedErr:   ErrKeyIDMismatch,
+		},
+		"missing keyid": {
+			keyBytes:      rsaPublicKey,
+			modify:        func(key *SSLibKey) { key.KeyID = "" },
+			expectedField: "keyid",
+			expectedErr:   ErrKeyIDMismatch,
+		},
+		"unknown keyid hash algorithm": {
+			keyBytes:      rsaPublicKey,
+			modify:        func(key *SSLibKey) { key.KeyIDHashAlgorithms = []string{"md5"} },
+			expectedField: "keyid_hash_algorithms",
+			expectedErr:   ErrUnknownKeyIDHashAlgorithm,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key := loadKey(t, test.keyBytes)
+			test.modify(key)
+
+			err := key.Validate()
+			assert.ErrorIs(t, err, test.expectedErr)
+
+			var validationErr *KeyValidationError
+			if assert.True(t, errors.As(err, &validationErr)) {
+				assert.Equal(t, test.expectedField, validationErr.Field)
+			}
+		})
+	}
+
+	t.Run("custom key type", func(t *testing.T) {
+		registerTestSignerVerifierFactory(t, "validate-custom", "validate-custom-scheme", func(key *SSLibKey) (dsse.SignerVerifier, error) {
+			return &customSignerVerifier{keyID: key.KeyID}, nil
+		})
+
+		key := &SSLibKey{
+			KeyIDHashAlgorithms: KeyIDHashAlgorithms,
+			KeyType:             "validate-custom",
+			Scheme:              "validate-custom-scheme",
+			KeyVal:              KeyVal{Public: "opaque public key"},
+		}
+		key.KeyID, _ = calculateKeyID(key)
+		assert.Nil(t, key.Validate())
+	})
+}
+
+func TestLoadSSLibKeyStrict(t *testing.T) {
+	contents, err := os.ReadFile(filepath.Join("test-data", "ecdsa-test-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := LoadSSLibKeyStrict(contents)
+	assert.Nil(t, err)
+	assert.Equal(t, "98adf38602c48c5479e9a991ee3f8cbf541ee4f985e00f7a5fc4148d9a45b704", key.KeyID)
+
+	t.Run("missing keyid is assigned", func(t *testing.T) {
+		withoutKeyID := map[string]any{}
+		if err := json.Unmarshal(contents, &withoutKeyID); err != nil {
+			t.Fatal(err)
+		}
+		delete(withoutKeyID, "keyid")
+		keyBytes, err := json.Marshal(withoutKeyID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key, err := LoadSSLibKeyStrict(keyBytes)
+		assert.Nil(t, err)
+		assert.Equal(t, "98adf38602c48c5479e9a991ee3f8cbf541ee4f985e00f7a5fc4148d9a45b704", key.KeyID)
+	})
+
+	t.Run("scheme does not match curve", func(t *testing.T) {
+		tampered := strings.Replace(string(contents), `"scheme": "ecdsa-sha2-nistp256"`, `"scheme": "ecdsa-sha2-nistp384"`, 1)
+
+		_, err := LoadSSLibKeyStrict([]byte(tampered))
+		assert.ErrorIs(t, err, ErrSchemeMismatch)
+	})
+
+	t.Run("PEM is not accepted", func(t *testing.T) {
+		_, err := LoadSSLibKeyStrict(ecdsaPublicKey)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("trailing data", func(t *testing.T) {
+		_, err := LoadSSLibKeyStrict(append(append([]byte{}, contents...), contents...))
+		assert.NotNil(t, err)
+	})
+}
+
+func TestLoadSSLibKeyStrictPythonKeys(t *testing.T) {
+	// Keys as exported by python-securesystemslib's SSlibKey, which leaves
+	// keyid_hash_algorithms out of both the key and its keyid.
+	tests := map[string]struct {
+		path          string
+		expectedKeyID string
+	}{
+		"ECDSA key": {
+			path:          "ecdsa-test-key-python.pub",
+			expectedKeyID: "31489a1a84c302191365915e2416473a3cacd83fb3dddc1172ff101f5e815195",
+		},
+		"sigstore-oidc key": {
+			path:          "sigstore-test-key-python.pub",
+			expectedKeyID: "ad901759c8e2ac38b71fe6b98011481b8068fb5439859dc22ac655e6a37a0e2c",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			contents, err := os.ReadFile(filepath.Join("test-data", test.path))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			key, err := LoadSSLibKeyStrict(contents)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expectedKeyID, key.KeyID)
+			assert.Empty(t, key.KeyIDHashAlgorithms)
+		})
+	}
+}